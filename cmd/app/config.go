@@ -0,0 +1,119 @@
+package main
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	_logic "github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/outbox"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/webhook"
+	"github.com/RomanUtolin/RESTful-CRUD/pkg/config"
+	"os"
+)
+
+// The settings of pkg/config are plain data, they are turned into the types of the packages using them here.
+
+func newJWTConfig(c config.Auth) middleware.JWTConfig {
+	return middleware.JWTConfig{
+		Algorithms:    c.JWT.Algorithms,
+		Secret:        c.JWT.Secret,
+		PublicKeyFile: c.JWT.PublicKeyFile,
+		JWKSFile:      c.JWT.JWKSFile,
+		Issuer:        c.JWT.Issuer,
+		Audience:      c.JWT.Audience,
+		Leeway:        c.JWT.Leeway.Duration(),
+		Realm:         c.Realm,
+	}
+}
+
+func newPolicyRules(c config.Policy) _logic.PolicyRules {
+	rules := _logic.PolicyRules{
+		Grants:      make(map[_logic.Operation][]string),
+		OwnerGrants: make(map[_logic.Operation][]string),
+	}
+	for operation, grants := range c.Grants {
+		rules.Grants[_logic.Operation(operation)] = grants
+	}
+	for operation, grants := range c.OwnerGrants {
+		rules.OwnerGrants[_logic.Operation(operation)] = grants
+	}
+	return rules
+}
+
+func newRateLimitRules(c config.RateLimit) []middleware.RateLimitRule {
+	rules := make([]middleware.RateLimitRule, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		rules = append(rules, middleware.RateLimitRule{
+			Route:    r.Route,
+			Tier:     r.Tier,
+			Requests: r.Requests,
+			Period:   r.Period.Duration(),
+		})
+	}
+	return rules
+}
+
+func newIPRateLimitRule(c config.RateLimit) middleware.RateLimitRule {
+	return middleware.RateLimitRule{Requests: c.PerIP.Requests, Period: c.PerIP.Period.Duration()}
+}
+
+func newCacheRules(c config.HTTPCache) []middleware.CacheRule {
+	rules := make([]middleware.CacheRule, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		rules = append(rules, middleware.CacheRule{Route: r.Route, CacheControl: r.CacheControl})
+	}
+	return rules
+}
+
+func newRelayConfig(c config.Outbox) outbox.Config {
+	return outbox.Config{
+		BatchSize:      c.BatchSize,
+		Interval:       c.Interval.Duration(),
+		Retention:      c.Retention.Duration(),
+		PublishTimeout: c.PublishTimeout.Duration(),
+		MaxAttempts:    c.MaxAttempts,
+	}
+}
+
+func newWebhookConfig(c config.Webhooks) webhook.Config {
+	return webhook.Config{
+		BatchSize:   c.BatchSize,
+		Interval:    c.Interval.Duration(),
+		Timeout:     c.Timeout.Duration(),
+		MaxAttempts: c.MaxAttempts,
+		Backoff:     c.Backoff.Duration(),
+		MaxBackoff:  c.MaxBackoff.Duration(),
+	}
+}
+
+func newCORSConfig(c config.CORS) middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowOrigins:     c.AllowOrigins,
+		AllowMethods:     c.AllowMethods,
+		AllowHeaders:     c.AllowHeaders,
+		ExposeHeaders:    c.ExposeHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge.Duration(),
+	}
+}
+
+func newTracingConfig(c config.Tracing) tracing.Config {
+	return tracing.Config{
+		Exporter:    c.Exporter,
+		Endpoint:    c.Endpoint,
+		Insecure:    c.Insecure,
+		ServiceName: c.ServiceName,
+		SampleRatio: c.SampleRatio,
+	}
+}
+
+// newPublisher opens the publisher of the outbox events, close releases its file.
+func newPublisher(c config.Outbox) (publisher outbox.Publisher, close func() error, err error) {
+	if c.Publisher != "file" {
+		return outbox.NewWriterPublisher(os.Stdout), func() error { return nil }, nil
+	}
+	file, err := os.OpenFile(c.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outbox.NewWriterPublisher(file), file.Close, nil
+}
@@ -1,31 +1,188 @@
 package main
 
 import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/health"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/http"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
 	_logic "github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/outbox"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
 	_repository "github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	_server "github.com/RomanUtolin/RESTful-CRUD/internall/server"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/webhook"
 	"github.com/RomanUtolin/RESTful-CRUD/pkg/config"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	config.GetLogger()
-	dbPoll := config.GetDb()
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		err := config.Print(os.Args[3:], os.Stdout)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	// registered first, the exit runs after every other deferred close
+	var failed bool
+	defer func() {
+		if failed {
+			os.Exit(1)
+		}
+	}()
+	config.GetLogger(cfg.Log)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	shutdownTracing, err := tracing.Setup(context.Background(), newTracingConfig(cfg.Tracing))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+	dbPoll, err := config.GetDb(ctx, cfg.Database, tracing.NewQueryTracer())
+	if err != nil {
+		logrus.Fatal(err)
+	}
 	defer dbPoll.Close()
+	replicaPools, err := config.GetReplicaDbs(cfg.Database, tracing.NewQueryTracer())
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	replicas := make([]_repository.DBPool, 0, len(replicaPools))
+	for _, pool := range replicaPools {
+		defer pool.Close()
+		replicas = append(replicas, pool)
+	}
+	dbRouter := _repository.NewDBRouter(dbPoll, replicas...)
+	dbRouter.CheckReplicas(ctx, cfg.Health.Timeout.Duration())
+	go dbRouter.Watch(ctx, cfg.Database.ReplicaCheckPeriod.Duration(), cfg.Health.Timeout.Duration())
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 	server := echo.New()
+	server.IPExtractor = trustedProxies.IPExtractor()
 	middl := middleware.InitMiddleware()
-	server.Use(middl.CORS)
+	server.Use(middl.ForwardedHeaders(trustedProxies))
+	server.Use(middl.RequestID)
+	server.Use(middl.Trace)
+	appMetrics := metrics.New()
+	if cfg.Metrics.Enabled {
+		appMetrics.RegisterPool("primary", dbPoll)
+		for i, pool := range replicaPools {
+			appMetrics.RegisterPool(cfg.Database.Replicas[i], pool)
+		}
+		server.Use(middl.Metrics(appMetrics))
+		server.GET(cfg.Metrics.Path, echo.WrapHandler(appMetrics.Handler()))
+	}
+	corsConfig := reload.NewValue(newCORSConfig(cfg.CORS))
+	server.Use(middl.CORS(corsConfig))
 	server.Use(middl.LogRequest)
-	repository := _repository.NewPersonRepository(dbPoll)
-	logic := _logic.NewPersonLogic(repository, config.GetTimeoutContext())
+	if len(replicas) > 0 {
+		server.Use(middl.ReadConsistency(cfg.Database.ReadAfterWrite.Duration()))
+	}
+	cacheRules := reload.NewValue(newCacheRules(cfg.HTTPCache))
+	vary := []string{echo.HeaderAccept}
+	if cfg.Auth.Enabled {
+		vary = append(vary, echo.HeaderAuthorization, middleware.HeaderAPIKey)
+	}
+	server.Use(middl.HTTPCache(cacheRules, vary))
+	apiKeyRepository := _repository.NewAPIKeyRepository(dbPoll)
+	repository := _repository.NewRoutedPersonRepository(dbRouter, cfg.Outbox.Enabled)
+	if cfg.Metrics.Enabled {
+		apiKeyRepository = _repository.NewInstrumentedAPIKeyRepository(apiKeyRepository, appMetrics)
+		repository = _repository.NewInstrumentedPersonRepository(repository, appMetrics)
+	}
+	if cfg.Cache.Enabled {
+		cache := _repository.NewMemoryCache(cfg.Cache.MaxEntries)
+		repository = _repository.NewCachedPersonRepository(repository, cache, cfg.Cache.TTL.Duration(), appMetrics)
+	}
+	webhookRepository := _repository.NewWebhookRepository(dbPoll)
+	if cfg.Outbox.Enabled {
+		publisher, closePublisher, err := newPublisher(cfg.Outbox)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer closePublisher()
+		if cfg.Webhooks.Enabled {
+			// deliveries are queued first, queueing again after a failure of the other publisher is a no-op
+			publisher = outbox.MultiPublisher{webhook.NewPublisher(webhookRepository), publisher}
+			go webhook.NewDispatcher(webhookRepository, newWebhookConfig(cfg.Webhooks)).Run(ctx)
+		}
+		relay := outbox.NewRelay(_repository.NewOutboxRepository(dbPoll, cfg.Outbox.BatchTimeout.Duration()), publisher, newRelayConfig(cfg.Outbox))
+		go relay.Run(ctx)
+	}
+	rateLimitStore := middleware.NewMemoryRateLimitStore()
+	ipRateLimitRule := reload.NewValue(newIPRateLimitRule(cfg.RateLimit))
+	rateLimitRules := reload.NewValue(newRateLimitRules(cfg.RateLimit))
+	if cfg.RateLimit.Enabled {
+		server.Use(middl.IPRateLimit(rateLimitStore, ipRateLimitRule))
+	}
+	contextTimeout := reload.NewValue(cfg.Context.Timeout.Duration())
+	apiKeyLogic := _logic.NewAPIKeyLogic(apiKeyRepository, contextTimeout)
+	if cfg.Auth.Enabled {
+		jwtAuth, err := middleware.NewJWTAuthenticator(newJWTConfig(cfg.Auth))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		apiKeyAuth := middleware.NewAPIKeyAuthenticator(apiKeyLogic, cfg.Auth.Realm)
+		server.Use(middl.Authenticate(cfg.Auth.PublicRoutes, jwtAuth, apiKeyAuth))
+	}
+	if cfg.RateLimit.Enabled {
+		server.Use(middl.RateLimit(rateLimitStore, rateLimitRules))
+	}
+	server.Use(middl.Idempotency(middleware.NewMemoryIdempotencyStore(), cfg.Idempotency.TTL.Duration(), cfg.Idempotency.MaxBodySize))
+	logic := _logic.NewPersonLogic(repository, contextTimeout)
+	if cfg.Auth.Enabled {
+		logic = _logic.NewPersonPolicy(logic, newPolicyRules(cfg.Policy))
+		// without authentication nobody could be checked against the policy, and keys issued then
+		// would become credentials once it is enabled
+		http.NewAPIKeyHandler(server, _logic.NewAPIKeyPolicy(apiKeyLogic, newPolicyRules(cfg.Policy)))
+	}
+	if cfg.Webhooks.Enabled && cfg.Auth.Enabled {
+		// like the API keys, subscriptions receive every person and must be managed by admins only
+		webhookLogic := _logic.NewWebhookPolicy(_logic.NewWebhookLogic(webhookRepository, contextTimeout), newPolicyRules(cfg.Policy))
+		http.NewWebhookHandler(server, webhookLogic)
+	}
+	logic = _logic.NewTracedPersonLogic(logic)
 	http.NewHandler(server, logic)
+	healthTimeout := reload.NewValue(cfg.Health.Timeout.Duration())
+	readiness := health.NewHealth(healthTimeout,
+		health.NewDatabaseCheck(dbPoll),
+		health.NewMigrationCheck(dbPoll, cfg.Database.MigrationVersion))
+	http.NewHealthHandler(server, readiness)
 
+	watcher := config.NewWatcher(cfg)
+	watcher.Subscribe(config.SubscriberFunc(func(cfg *config.Config) {
+		config.SetLogLevel(cfg.Log)
+		contextTimeout.Store(cfg.Context.Timeout.Duration())
+		healthTimeout.Store(cfg.Health.Timeout.Duration())
+		ipRateLimitRule.Store(newIPRateLimitRule(cfg.RateLimit))
+		rateLimitRules.Store(newRateLimitRules(cfg.RateLimit))
+		corsConfig.Store(newCORSConfig(cfg.CORS))
+		cacheRules.Store(newCacheRules(cfg.HTTPCache))
+	}))
+	go watcher.Watch(ctx)
+	app := &_server.Server{
+		Echo:            server,
+		Health:          readiness,
+		DrainDelay:      cfg.Server.DrainDelay.Duration(),
+		ShutdownTimeout: cfg.Server.ShutdownTimeout.Duration(),
+	}
 	logrus.Infof("Starting Server")
-	err := server.Start(viper.GetString("server.address"))
+	err = app.Run(ctx, cfg.Server.Address)
 	if err != nil {
-		logrus.Warning(err)
+		// a server that could not start or stop cleanly must not look like a clean stop to orchestrators
+		logrus.WithField("Error", err).Error("server failed")
+		failed = true
 	}
 }
@@ -0,0 +1,18 @@
+package consistency
+
+import "context"
+
+type contextKey int
+
+const primaryKey contextKey = iota
+
+// WithPrimary returns a copy of ctx whose reads must go to the primary database, to see the writes made before.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// PrimaryRequired tells whether the reads of ctx must go to the primary database.
+func PrimaryRequired(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey).(bool)
+	return primary
+}
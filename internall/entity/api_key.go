@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+)
+
+// APIKeyPrefix starts every API key so that secret scanners can recognise leaked keys.
+const APIKeyPrefix = "rcrud_"
+
+type APIKey struct {
+	XMLName    xml.Name   `json:"-" xml:"api_key" msgpack:"-" cbor:"-"`
+	ID         int        `json:"id" xml:"id" msgpack:"id" cbor:"id"`
+	Name       string     `json:"name" xml:"name" msgpack:"name" cbor:"name" validate:"required,max=255"`
+	Prefix     string     `json:"prefix" xml:"prefix" msgpack:"prefix" cbor:"prefix"`
+	Scopes     []string   `json:"scopes" xml:"scopes>scope" msgpack:"scopes" cbor:"scopes" validate:"required,dive,required"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" xml:"expires_at,omitempty" msgpack:"expires_at,omitempty" cbor:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" xml:"last_used_at,omitempty" msgpack:"last_used_at,omitempty" cbor:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" xml:"revoked_at,omitempty" msgpack:"revoked_at,omitempty" cbor:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at" msgpack:"created_at" cbor:"created_at"`
+	// Key is the plaintext key, it is only set in the answer to issuing or rotating the key.
+	Key  string `json:"key,omitempty" xml:"key,omitempty" msgpack:"key,omitempty" cbor:"key,omitempty"`
+	Hash string `json:"-" xml:"-" msgpack:"-" cbor:"-"`
+}
+
+type APIKeyRepository interface {
+	GetAll(ctx context.Context) ([]*APIKey, error)
+	GetByID(ctx context.Context, id int) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	Create(ctx context.Context, req *APIKey) (*APIKey, error)
+	Rotate(ctx context.Context, id int, prefix, hash string) error
+	Revoke(ctx context.Context, id int) error
+	TouchLastUsed(ctx context.Context, id int) error
+}
+
+type APIKeyLogic interface {
+	GetAll(ctx context.Context) ([]*APIKey, error)
+	Issue(ctx context.Context, req *APIKey) (*APIKey, error)
+	Rotate(ctx context.Context, id int) (*APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, key string) (*APIKey, error)
+}
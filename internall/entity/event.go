@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrEventFailed marks the error of an event that is not published again.
+var ErrEventFailed = errors.New("outbox event failed")
+
+type EventType string
+
+const (
+	PersonCreated EventType = "PersonCreated"
+	PersonUpdated EventType = "PersonUpdated"
+	PersonDeleted EventType = "PersonDeleted"
+)
+
+// Event is a change of a person recorded in the outbox with the write itself. Before is nil for
+// PersonCreated, After for PersonDeleted.
+type Event struct {
+	ID         int       `json:"id"`
+	Type       EventType `json:"type"`
+	PersonID   int       `json:"person_id"`
+	Before     *Person   `json:"before"`
+	After      *Person   `json:"after"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Attempts is the number of failed attempts to publish the event so far.
+	Attempts int `json:"-"`
+}
+
+type OutboxRepository interface {
+	// Process passes the oldest unpublished events, at most limit, to process while no other relay can,
+	// with a context that ends when another relay may take over. It then marks the events in the result
+	// published, or records the error they failed with. Events whose error wraps ErrEventFailed are failed
+	// and not passed again. It returns the number of events passed, 0 as well when another relay is processing.
+	Process(ctx context.Context, limit int, process func(ctx context.Context, events []*Event) map[int]error) (int, error)
+	// DeletePublished removes the events published before.
+	DeletePublished(ctx context.Context, before time.Time) (int, error)
+}
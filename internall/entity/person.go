@@ -1,20 +1,48 @@
 package entity
 
-import "context"
+import (
+	"context"
+	"encoding/xml"
+	"time"
+)
 
 type Person struct {
-	ID        int    `json:"id"`
-	Email     string `json:"email" validate:"required"`
-	Phone     string `json:"phone" validate:"required"`
-	FirstName string `json:"first_name" validate:"required,min=3,max=50"`
+	XMLName   xml.Name `json:"-" xml:"person" msgpack:"-" cbor:"-"`
+	ID        int      `json:"id,omitempty" xml:"id,omitempty" msgpack:"id,omitempty" cbor:"id,omitempty"`
+	Email     string   `json:"email,omitempty" xml:"email,omitempty" msgpack:"email,omitempty" cbor:"email,omitempty" validate:"required"`
+	Phone     string   `json:"phone,omitempty" xml:"phone,omitempty" msgpack:"phone,omitempty" cbor:"phone,omitempty" validate:"required"`
+	FirstName string   `json:"first_name,omitempty" xml:"first_name,omitempty" msgpack:"first_name,omitempty" cbor:"first_name,omitempty" validate:"required,min=3,max=50"`
+	// UpdatedAt is set by the repository on every write, it is ignored in requests.
+	UpdatedAt *time.Time `json:"updated_at,omitempty" xml:"updated_at,omitempty" msgpack:"updated_at,omitempty" cbor:"updated_at,omitempty"`
+}
+
+// PersonFields are the names accepted by sparse fieldsets, "id" and "updated_at" are always selected.
+var PersonFields = []string{"id", "email", "phone", "first_name"}
+
+type CountMode string
+
+const (
+	CountExact     CountMode = "exact"
+	CountEstimated CountMode = "estimated"
+	CountNone      CountMode = "none"
+)
+
+// PersonPage is one page of persons, Total and LastPage are left zero for CountNone.
+type PersonPage struct {
+	Persons  []*Person
+	Total    int
+	Page     int
+	LastPage int
+	HasMore  bool
+	Count    CountMode
 }
 
 type PersonRepository interface {
-	GetAll(ctx context.Context, limit, offset int) ([]*Person, error)
-	GetAllByEmail(ctx context.Context, email string, limit, offset int) ([]*Person, error)
-	GetAllByPhone(ctx context.Context, phone string, limit, offset int) ([]*Person, error)
-	GetAllByName(ctx context.Context, firstName string, limit, offset int) ([]*Person, error)
-	GetByID(ctx context.Context, id int) (*Person, error)
+	GetAll(ctx context.Context, fields []string, limit, offset int) ([]*Person, error)
+	GetAllByEmail(ctx context.Context, email string, fields []string, limit, offset int) ([]*Person, error)
+	GetAllByPhone(ctx context.Context, phone string, fields []string, limit, offset int) ([]*Person, error)
+	GetAllByName(ctx context.Context, firstName string, fields []string, limit, offset int) ([]*Person, error)
+	GetByID(ctx context.Context, id int, fields []string) (*Person, error)
 	GetByEmail(ctx context.Context, email string) (*Person, error)
 	Create(ctx context.Context, req *Person) (*Person, error)
 	Update(ctx context.Context, id int, req *Person) (*Person, error)
@@ -23,12 +51,16 @@ type PersonRepository interface {
 	CountAllByEmail(ctx context.Context, email string) (int, error)
 	CountAllByPhone(ctx context.Context, phone string) (int, error)
 	CountAllByName(ctx context.Context, name string) (int, error)
+	EstimateCountAll(ctx context.Context) (int, error)
+	EstimateCountAllByEmail(ctx context.Context, email string) (int, error)
+	EstimateCountAllByPhone(ctx context.Context, phone string) (int, error)
+	EstimateCountAllByName(ctx context.Context, name string) (int, error)
 	ParseData(data []byte) (*Person, error)
 }
 
 type PersonLogic interface {
-	GetPersons(ctx context.Context, email, phone, firstName string, page, limit int) ([]*Person, int, int, int, error)
-	GetOnePerson(ctx context.Context, id int) (*Person, error)
+	GetPersons(ctx context.Context, email, phone, firstName string, fields []string, page, limit int, count CountMode) (*PersonPage, error)
+	GetOnePerson(ctx context.Context, id int, fields []string) (*Person, error)
 	Create(ctx context.Context, req *Person) (*Person, error)
 	Update(ctx context.Context, id int, req *Person) (*Person, error)
 	Delete(ctx context.Context, id int) error
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// WebhookSecretPrefix starts every generated webhook secret.
+const WebhookSecretPrefix = "whsec_"
+
+// Webhook subscribes URL to the person events of Events, to every event when Events is empty.
+type Webhook struct {
+	XMLName xml.Name    `json:"-" xml:"webhook" msgpack:"-" cbor:"-"`
+	ID      int         `json:"id" xml:"id" msgpack:"id" cbor:"id"`
+	URL     string      `json:"url" xml:"url" msgpack:"url" cbor:"url" validate:"required,url"`
+	Events  []EventType `json:"events" xml:"events>event" msgpack:"events" cbor:"events" validate:"dive,oneof=PersonCreated PersonUpdated PersonDeleted"`
+	// Secret signs the payloads, it is only set in the answer to creating the webhook or changing the secret.
+	Secret    string    `json:"secret,omitempty" xml:"secret,omitempty" msgpack:"secret,omitempty" cbor:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" msgpack:"created_at" cbor:"created_at"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead is a delivery that failed every attempt, it is only sent again when redelivered.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery is an event to send to a webhook, with the outcome of its last attempt.
+type WebhookDelivery struct {
+	XMLName        xml.Name        `json:"-" xml:"delivery" msgpack:"-" cbor:"-"`
+	ID             int             `json:"id" xml:"id" msgpack:"id" cbor:"id"`
+	WebhookID      int             `json:"webhook_id" xml:"webhook_id" msgpack:"webhook_id" cbor:"webhook_id"`
+	EventID        int             `json:"event_id" xml:"event_id" msgpack:"event_id" cbor:"event_id"`
+	EventType      EventType       `json:"event_type" xml:"event_type" msgpack:"event_type" cbor:"event_type"`
+	Payload        json.RawMessage `json:"payload" xml:"payload" msgpack:"payload" cbor:"payload"`
+	Status         DeliveryStatus  `json:"status" xml:"status" msgpack:"status" cbor:"status"`
+	Attempts       int             `json:"attempts" xml:"attempts" msgpack:"attempts" cbor:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty" xml:"response_status,omitempty" msgpack:"response_status,omitempty" cbor:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty" xml:"last_error,omitempty" msgpack:"last_error,omitempty" cbor:"last_error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" xml:"next_attempt_at,omitempty" msgpack:"next_attempt_at,omitempty" cbor:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" xml:"delivered_at,omitempty" msgpack:"delivered_at,omitempty" cbor:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at" xml:"created_at" msgpack:"created_at" cbor:"created_at"`
+	// URL and Secret of the webhook are only set on claimed deliveries.
+	URL    string `json:"-" xml:"-" msgpack:"-" cbor:"-"`
+	Secret string `json:"-" xml:"-" msgpack:"-" cbor:"-"`
+}
+
+type WebhookRepository interface {
+	GetAll(ctx context.Context) ([]*Webhook, error)
+	GetByID(ctx context.Context, id int) (*Webhook, error)
+	Create(ctx context.Context, req *Webhook) (*Webhook, error)
+	// Update changes URL and Events, and Secret unless it is empty.
+	Update(ctx context.Context, id int, req *Webhook) (*Webhook, error)
+	Delete(ctx context.Context, id int) error
+	// Enqueue adds a pending delivery of event to every webhook subscribed to it, once per webhook.
+	Enqueue(ctx context.Context, event *Event) (int, error)
+	// Claim returns the pending deliveries due, at most limit, and holds them back from other claims for lease.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// RecordAttempt stores the status and outcome of the last attempt of delivery.
+	RecordAttempt(ctx context.Context, delivery *WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookID int, limit int) ([]*WebhookDelivery, error)
+	// Redeliver makes the delivery pending and due again with all its attempts.
+	Redeliver(ctx context.Context, webhookID, id int) (*WebhookDelivery, error)
+}
+
+type WebhookLogic interface {
+	GetAll(ctx context.Context) ([]*Webhook, error)
+	GetByID(ctx context.Context, id int) (*Webhook, error)
+	Create(ctx context.Context, req *Webhook) (*Webhook, error)
+	Update(ctx context.Context, id int, req *Webhook) (*Webhook, error)
+	Delete(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookID int) ([]*WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID, id int) (*WebhookDelivery, error)
+}
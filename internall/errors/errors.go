@@ -3,8 +3,11 @@ package errors
 import "errors"
 
 var (
-	ErrNotFound       = errors.New("your requested item is not found")
-	ErrConflict       = errors.New("your email already exist, must be unique")
-	ErrBadParamInput  = errors.New("given param is not valid")
-	ErrInternalServer = errors.New("internal Server Error")
+	ErrNotFound             = errors.New("your requested item is not found")
+	ErrConflict             = errors.New("your email already exist, must be unique")
+	ErrBadParamInput        = errors.New("given param is not valid")
+	ErrInternalServer       = errors.New("internal Server Error")
+	ErrForbidden            = errors.New("you are not allowed to perform this operation")
+	ErrNotAcceptable        = errors.New("requested media type is not acceptable")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
 )
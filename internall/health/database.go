@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// DB is the part of *pgxpool.Pool the database checks use.
+type DB interface {
+	Ping(ctx context.Context) error
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type DatabaseCheck struct {
+	db DB
+}
+
+func NewDatabaseCheck(db DB) *DatabaseCheck {
+	return &DatabaseCheck{db: db}
+}
+
+func (c *DatabaseCheck) Name() string {
+	return "database"
+}
+
+func (c *DatabaseCheck) Check(ctx context.Context) error {
+	return c.db.Ping(ctx)
+}
+
+// MigrationCheck fails until the schema has the version the code was written for, with every migration
+// up to it applied. Each file of migrations/up records its own version.
+type MigrationCheck struct {
+	db      DB
+	version int
+}
+
+func NewMigrationCheck(db DB, version int) *MigrationCheck {
+	return &MigrationCheck{db: db, version: version}
+}
+
+func (c *MigrationCheck) Name() string {
+	return "migrations"
+}
+
+func (c *MigrationCheck) Check(ctx context.Context) error {
+	sql := `SELECT COALESCE(MAX(version), 0), count(*) FILTER (WHERE version BETWEEN 1 AND $1)
+			FROM schema_migrations;`
+	var version, applied int
+	err := c.db.QueryRow(ctx, sql, c.version).Scan(&version, &applied)
+	if err != nil {
+		return err
+	}
+	if version != c.version {
+		return fmt.Errorf("schema version %d, want %d", version, c.version)
+	}
+	if applied != c.version {
+		return fmt.Errorf("schema version %d misses %d of its migrations", version, c.version-applied)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is a dependency the service needs to serve requests.
+type Check interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status   string                  `json:"status"`
+	Draining bool                    `json:"draining,omitempty"`
+	Checks   map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+type Health struct {
+	checks   []Check
+	timeout  *reload.Value[time.Duration]
+	draining atomic.Bool
+}
+
+func NewHealth(timeout *reload.Value[time.Duration], checks ...Check) *Health {
+	return &Health{checks: checks, timeout: timeout}
+}
+
+// Drain makes the service report not ready from now on, so that it gets no new traffic while shutting down.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Readiness runs every check concurrently, each within the timeout.
+func (h *Health) Readiness(ctx context.Context) *Report {
+	if h.draining.Load() {
+		return &Report{Status: StatusFail, Draining: true}
+	}
+	report := &Report{Status: StatusOK, Checks: make(map[string]*CheckResult, len(h.checks))}
+	results := make([]*CheckResult, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = h.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+	for i, check := range h.checks {
+		report.Checks[check.Name()] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check Check) *CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout.Load())
+	defer cancel()
+	start := time.Now()
+	err := check.Check(ctx)
+	result := &CheckResult{Status: StatusOK, DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
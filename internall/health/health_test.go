@@ -0,0 +1,117 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/health"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type versionRow struct {
+	version int
+	applied int
+	err     error
+}
+
+func (r versionRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int) = r.version
+	*dest[1].(*int) = r.applied
+	return nil
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockFunc   func(db *mocks.DB)
+		waitStatus string
+		waitChecks map[string]string
+		waitError  string
+	}{
+		{
+			name: "ready",
+			mockFunc: func(db *mocks.DB) {
+				db.On("Ping", mock.Anything).Return(nil)
+				db.On("QueryRow", mock.Anything, mock.Anything, 2).Return(versionRow{version: 2, applied: 2})
+			},
+			waitStatus: health.StatusOK,
+			waitChecks: map[string]string{"database": health.StatusOK, "migrations": health.StatusOK},
+		},
+		{
+			name: "database down",
+			mockFunc: func(db *mocks.DB) {
+				db.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+				db.On("QueryRow", mock.Anything, mock.Anything, 2).Return(versionRow{err: errors.New("connection refused")})
+			},
+			waitStatus: health.StatusFail,
+			waitChecks: map[string]string{"database": health.StatusFail, "migrations": health.StatusFail},
+		},
+		{
+			name: "old schema",
+			mockFunc: func(db *mocks.DB) {
+				db.On("Ping", mock.Anything).Return(nil)
+				db.On("QueryRow", mock.Anything, mock.Anything, 2).Return(versionRow{version: 0})
+			},
+			waitStatus: health.StatusFail,
+			waitChecks: map[string]string{"database": health.StatusOK, "migrations": health.StatusFail},
+			waitError:  "schema version 0, want 2",
+		},
+		{
+			name: "missing migration",
+			mockFunc: func(db *mocks.DB) {
+				db.On("Ping", mock.Anything).Return(nil)
+				db.On("QueryRow", mock.Anything, mock.Anything, 2).Return(versionRow{version: 2, applied: 1})
+			},
+			waitStatus: health.StatusFail,
+			waitChecks: map[string]string{"database": health.StatusOK, "migrations": health.StatusFail},
+			waitError:  "schema version 2 misses 1 of its migrations",
+		},
+		{
+			name: "timeout",
+			mockFunc: func(db *mocks.DB) {
+				db.On("Ping", mock.Anything).Return(func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+				db.On("QueryRow", mock.Anything, mock.Anything, 2).Return(versionRow{version: 2, applied: 2})
+			},
+			waitStatus: health.StatusFail,
+			waitChecks: map[string]string{"database": health.StatusFail, "migrations": health.StatusOK},
+		},
+	}
+	for _, test := range tests {
+		db := new(mocks.DB)
+		test.mockFunc(db)
+		h := health.NewHealth(reload.NewValue(50*time.Millisecond), health.NewDatabaseCheck(db), health.NewMigrationCheck(db, 2))
+
+		report := h.Readiness(context.Background())
+		assert.Equal(t, test.waitStatus, report.Status, test.name)
+		for name, status := range test.waitChecks {
+			assert.Equal(t, status, report.Checks[name].Status, test.name+" "+name)
+		}
+		if test.waitError != "" {
+			assert.Equal(t, test.waitError, report.Checks["migrations"].Error, test.name)
+		}
+	}
+}
+
+func TestHealth_Drain(t *testing.T) {
+	check := new(mocks.Check)
+	check.On("Name").Return("database")
+	check.On("Check", mock.Anything).Return(nil).Once()
+	h := health.NewHealth(reload.NewValue(time.Second), check)
+
+	assert.Equal(t, health.StatusOK, h.Readiness(context.Background()).Status)
+	h.Drain()
+	report := h.Readiness(context.Background())
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.True(t, report.Draining)
+	check.AssertExpectations(t)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/xml"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/render"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type APIKeyHandler struct {
+	Logic entity.APIKeyLogic
+}
+
+type ResponseAPIKeys struct {
+	XMLName xml.Name         `json:"-" xml:"response" msgpack:"-" cbor:"-"`
+	Data    []*entity.APIKey `json:"data" xml:"data>api_key" msgpack:"data" cbor:"data"`
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.Logic.GetAll(c.Request().Context())
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Info("Get API keys Successful")
+	return render.Respond(c, http.StatusOK, &ResponseAPIKeys{Data: keys})
+}
+
+func (h *APIKeyHandler) IssueAPIKey(c echo.Context) error {
+	req := &entity.APIKey{}
+	err := render.Bind(c, req)
+	if err != nil {
+		return getError(c, err)
+	}
+	key, err := h.Logic.Issue(c.Request().Context(), req)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Issue API key id = %v Successful", key.ID)
+	return respondSecret(c, http.StatusCreated, key)
+}
+
+func (h *APIKeyHandler) RotateAPIKey(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	key, err := h.Logic.Rotate(c.Request().Context(), id)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Rotate API key id = %v Successful", id)
+	return respondSecret(c, http.StatusOK, key)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := h.Logic.Revoke(c.Request().Context(), id)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Revoke API key id = %v Successful", id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func NewAPIKeyHandler(e *echo.Echo, logic entity.APIKeyLogic) {
+	handler := &APIKeyHandler{Logic: logic}
+	e.GET("/admin/api-keys", handler.GetAPIKeys, negotiate)
+	e.POST("/admin/api-keys", handler.IssueAPIKey, negotiate)
+	e.POST("/admin/api-keys/:id/rotate", handler.RotateAPIKey, negotiate)
+	e.DELETE("/admin/api-keys/:id", handler.RevokeAPIKey, negotiate)
+}
+
+// respondSecret answers with a plaintext secret, shown once: it must be neither cached nor replayed.
+func respondSecret(c echo.Context, status int, v interface{}) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return render.Respond(c, status, v)
+}
@@ -0,0 +1,130 @@
+package http_test
+
+import (
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	personHandler "github.com/RomanUtolin/RESTful-CRUD/internall/http"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testAPIKey        = &entity.APIKey{ID: 1, Name: "batch", Prefix: "rcrud_01234567", Scopes: []string{"person:read"}}
+	testIssuedKey     = &entity.APIKey{ID: 1, Name: "batch", Prefix: "rcrud_01234567", Scopes: []string{"person:read"}, Key: "rcrud_0123456789"}
+	testAPIKeyReq     = &entity.APIKey{Name: "batch", Scopes: []string{"person:read"}}
+	testAPIKeyReqJson = `{"name":"batch","scopes":["person:read"]}`
+)
+
+func TestAPIKeyHandler(t *testing.T) {
+	listJson, _ := json.Marshal(personHandler.ResponseAPIKeys{Data: []*entity.APIKey{testAPIKey}})
+	issuedJson, _ := json.Marshal(testIssuedKey)
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		mockFunc     func(mockUCase *mocks.APIKeyLogic)
+		waitCode     int
+		waitResponse string
+		waitNoStore  bool
+	}{
+		{
+			name:   "list",
+			method: echo.GET,
+			path:   "/admin/api-keys",
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("GetAll", mock.Anything).Return([]*entity.APIKey{testAPIKey}, nil)
+			},
+			waitCode:     http.StatusOK,
+			waitResponse: string(listJson),
+		},
+		{
+			name:   "list forbidden",
+			method: echo.GET,
+			path:   "/admin/api-keys",
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("GetAll", mock.Anything).Return(nil, serverErr.ErrForbidden)
+			},
+			waitCode:     http.StatusForbidden,
+			waitResponse: string(jsonErrForbidden),
+		},
+		{
+			name:   "issue",
+			method: echo.POST,
+			path:   "/admin/api-keys",
+			body:   testAPIKeyReqJson,
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("Issue", mock.Anything, testAPIKeyReq).Return(testIssuedKey, nil)
+			},
+			waitCode:     http.StatusCreated,
+			waitResponse: string(issuedJson),
+			waitNoStore:  true,
+		},
+		{
+			name:   "issue invalid",
+			method: echo.POST,
+			path:   "/admin/api-keys",
+			body:   `{"name":""}`,
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("Issue", mock.Anything, &entity.APIKey{}).Return(nil, serverErr.ErrBadParamInput)
+			},
+			waitCode:     http.StatusBadRequest,
+			waitResponse: string(jsonErrBadParam),
+		},
+		{
+			name:   "rotate",
+			method: echo.POST,
+			path:   "/admin/api-keys/1/rotate",
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("Rotate", mock.Anything, 1).Return(testIssuedKey, nil)
+			},
+			waitCode:     http.StatusOK,
+			waitResponse: string(issuedJson),
+			waitNoStore:  true,
+		},
+		{
+			name:   "revoke",
+			method: echo.DELETE,
+			path:   "/admin/api-keys/1",
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("Revoke", mock.Anything, 1).Return(nil)
+			},
+			waitCode: http.StatusNoContent,
+		},
+		{
+			name:   "revoke not found",
+			method: echo.DELETE,
+			path:   "/admin/api-keys/2",
+			mockFunc: func(mockUCase *mocks.APIKeyLogic) {
+				mockUCase.On("Revoke", mock.Anything, 2).Return(serverErr.ErrNotFound)
+			},
+			waitCode:     http.StatusNotFound,
+			waitResponse: string(jsonErrNotFound),
+		},
+	}
+	for _, test := range tests {
+		mockUCase := new(mocks.APIKeyLogic)
+		test.mockFunc(mockUCase)
+		e := echo.New()
+		personHandler.NewAPIKeyHandler(e, mockUCase)
+
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if test.body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		assert.Equal(t, test.waitResponse, strings.Trim(rec.Body.String(), "\n"), test.name)
+		assert.Equal(t, test.waitNoStore, rec.Header().Get(echo.HeaderCacheControl) == "no-store", test.name)
+		mockUCase.AssertExpectations(t)
+	}
+}
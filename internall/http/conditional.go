@@ -0,0 +1,23 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const headerIfNoneMatch = "If-None-Match"
+
+// notModified sets Last-Modified to modified and tells whether the copy the client holds by If-Modified-Since
+// is still current. HTTP dates have whole seconds, modified is truncated to them.
+func notModified(c echo.Context, modified time.Time) bool {
+	modified = modified.UTC().Truncate(time.Second)
+	c.Response().Header().Set(echo.HeaderLastModified, modified.Format(http.TimeFormat))
+	req := c.Request()
+	if req.Header.Get(headerIfNoneMatch) != "" {
+		// If-None-Match takes precedence and no ETag is sent to match it
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get(echo.HeaderIfModifiedSince))
+	return err == nil && !modified.After(since)
+}
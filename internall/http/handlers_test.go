@@ -12,8 +12,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -26,33 +29,51 @@ var (
 	jsonErrConflict, _       = json.Marshal(personHandler.ResponseError{Message: serverErr.ErrConflict.Error()})
 	jsonErrNotFound, _       = json.Marshal(personHandler.ResponseError{Message: serverErr.ErrNotFound.Error()})
 	jsonErrBadParam, _       = json.Marshal(personHandler.ResponseError{Message: serverErr.ErrBadParamInput.Error()})
+	jsonErrForbidden, _      = json.Marshal(personHandler.ResponseError{Message: serverErr.ErrForbidden.Error()})
 )
 
+func resources(persons ...*entity.Person) []*personHandler.PersonResource {
+	result := make([]*personHandler.PersonResource, 0)
+	for _, person := range persons {
+		result = append(result, &personHandler.PersonResource{
+			Person: person,
+			Links:  &personHandler.Links{Self: &personHandler.Link{Href: "http://example.com/person/" + strconv.Itoa(person.ID)}},
+		})
+	}
+	return result
+}
+
+func pageLinks(query string, page, lastPage int) *personHandler.Links {
+	href := func(p int) *personHandler.Link {
+		values, _ := url.ParseQuery(query)
+		values.Set("page", strconv.Itoa(p))
+		return &personHandler.Link{Href: "http://example.com/person?" + values.Encode()}
+	}
+	links := &personHandler.Links{Self: href(page), First: href(1), Last: href(lastPage)}
+	if page > 1 {
+		links.Prev = href(page - 1)
+	}
+	if page < lastPage {
+		links.Next = href(page + 1)
+	}
+	return links
+}
+
 func TestHandler_GetPersons(t *testing.T) {
 	ListPerson := make([]*entity.Person, 0)
 	ListOnePerson := append(ListPerson, testPerson)
 	ListTwoPerson := append(ListOnePerson, testPerson2)
-	dataOnePerson := &personHandler.ResponseData{
-		Data:     ListOnePerson,
-		Total:    1,
-		Page:     1,
-		LastPage: 1,
-	}
-	dataTwoPerson := &personHandler.ResponseData{
-		Data:     ListTwoPerson,
-		Total:    2,
-		Page:     1,
-		LastPage: 1,
+	dataResponse := func(query string, total, lastPage int, persons ...*entity.Person) string {
+		data, _ := json.Marshal(personHandler.ResponseData{
+			Data:     resources(persons...),
+			Total:    &total,
+			Page:     1,
+			LastPage: &lastPage,
+			HasMore:  lastPage > 1,
+			Links:    pageLinks(query, 1, lastPage),
+		})
+		return string(data)
 	}
-	dataTwoPersonLimit := &personHandler.ResponseData{
-		Data:     ListOnePerson,
-		Total:    2,
-		Page:     1,
-		LastPage: 2,
-	}
-	jsonOnePerson, _ := json.Marshal(*dataOnePerson)
-	jsonTwoPerson, _ := json.Marshal(*dataTwoPerson)
-	jsonTwoPersonLimit, _ := json.Marshal(*dataTwoPersonLimit)
 
 	tests := []struct {
 		name         string
@@ -64,54 +85,54 @@ func TestHandler_GetPersons(t *testing.T) {
 		{
 			name: "valid",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetPersons", mock.Anything, "", "", "", 0, 0).Return(ListTwoPerson, 2, 1, 1, nil)
+				mockUCase.On("GetPersons", mock.Anything, "", "", "", []string(nil), 0, 0, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListTwoPerson, Total: 2, Page: 1, LastPage: 1, Count: entity.CountExact}, nil)
 			},
-			path:         "person",
+			path:         "/person",
 			waitCode:     http.StatusOK,
-			waitResponse: string(jsonTwoPerson),
+			waitResponse: dataResponse("", 2, 1, testPerson, testPerson2),
 		},
 		{
 			name: "valid with param email",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetPersons", mock.Anything, testPerson.Email, "", "", 0, 0).Return(ListOnePerson, 1, 1, 1, nil)
+				mockUCase.On("GetPersons", mock.Anything, testPerson.Email, "", "", []string(nil), 0, 0, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListOnePerson, Total: 1, Page: 1, LastPage: 1, Count: entity.CountExact}, nil)
 			},
-			path:         "person?email=test@test.ru",
+			path:         "/person?email=test@test.ru",
 			waitCode:     http.StatusOK,
-			waitResponse: string(jsonOnePerson),
+			waitResponse: dataResponse("email=test%40test.ru", 1, 1, testPerson),
 		},
 		{
 			name: "valid with param phone",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetPersons", mock.Anything, "", testPerson.Phone, "", 0, 0).Return(ListOnePerson, 1, 1, 1, nil)
+				mockUCase.On("GetPersons", mock.Anything, "", testPerson.Phone, "", []string(nil), 0, 0, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListOnePerson, Total: 1, Page: 1, LastPage: 1, Count: entity.CountExact}, nil)
 			},
-			path:         "person?phone=1234",
+			path:         "/person?phone=1234",
 			waitCode:     http.StatusOK,
-			waitResponse: string(jsonOnePerson),
+			waitResponse: dataResponse("phone=1234", 1, 1, testPerson),
 		},
 		{
 			name: "valid with param name",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetPersons", mock.Anything, "", "", testPerson.FirstName, 0, 0).Return(ListOnePerson, 1, 1, 1, nil)
+				mockUCase.On("GetPersons", mock.Anything, "", "", testPerson.FirstName, []string(nil), 0, 0, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListOnePerson, Total: 1, Page: 1, LastPage: 1, Count: entity.CountExact}, nil)
 			},
-			path:         "person?first_name=test",
+			path:         "/person?first_name=test",
 			waitCode:     http.StatusOK,
-			waitResponse: string(jsonOnePerson),
+			waitResponse: dataResponse("first_name=test", 1, 1, testPerson),
 		},
 		{
 			name: "valid page=1&limit=1 all page 2",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetPersons", mock.Anything, "", "", "", 1, 1).Return(ListOnePerson, 2, 1, 2, nil)
+				mockUCase.On("GetPersons", mock.Anything, "", "", "", []string(nil), 1, 1, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListOnePerson, Total: 2, Page: 1, LastPage: 2, HasMore: true, Count: entity.CountExact}, nil)
 			},
-			path:         "person?page=1&limit=1",
+			path:         "/person?page=1&limit=1",
 			waitCode:     http.StatusOK,
-			waitResponse: string(jsonTwoPersonLimit),
+			waitResponse: dataResponse("limit=1", 2, 2, testPerson),
 		},
 		{
 			name: "store error",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetPersons", mock.Anything, "", "", "", 0, 0).Return(nil, 0, 1, 1, serverErr.ErrInternalServer)
+				mockUCase.On("GetPersons", mock.Anything, "", "", "", []string(nil), 0, 0, entity.CountMode("")).Return(nil, serverErr.ErrInternalServer)
 			},
-			path:         "person",
+			path:         "/person",
 			waitCode:     http.StatusInternalServerError,
 			waitResponse: string(jsonErrServer),
 		},
@@ -122,14 +143,13 @@ func TestHandler_GetPersons(t *testing.T) {
 
 		e := echo.New()
 
-		req, err := http.NewRequest(echo.GET, test.path, strings.NewReader(""))
-		assert.NoError(t, err)
+		req := httptest.NewRequest(echo.GET, test.path, strings.NewReader(""))
 
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
 		handler := personHandler.Handler{Logic: mockUCase}
-		err = handler.GetPersons(c)
+		err := handler.GetPersons(c)
 
 		require.NoError(t, err)
 		assert.Equal(t, test.waitCode, rec.Code)
@@ -150,7 +170,7 @@ func TestHandler_GetPerson(t *testing.T) {
 			name: "valid",
 			id:   "1",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID).Return(testPerson, nil)
+				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
 			},
 			waitCode:     http.StatusOK,
 			waitResponse: string(PersonJson),
@@ -159,7 +179,7 @@ func TestHandler_GetPerson(t *testing.T) {
 			name: "store error",
 			id:   "1",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID).Return(nil, serverErr.ErrInternalServer)
+				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(nil, serverErr.ErrInternalServer)
 			},
 			waitCode:     http.StatusInternalServerError,
 			waitResponse: string(jsonErrServer),
@@ -168,7 +188,7 @@ func TestHandler_GetPerson(t *testing.T) {
 			name: "id valid, in db not found",
 			id:   "1",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID).Return(nil, serverErr.ErrNotFound)
+				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(nil, serverErr.ErrNotFound)
 			},
 			waitCode:     http.StatusNotFound,
 			waitResponse: string(jsonErrNotFound),
@@ -177,7 +197,7 @@ func TestHandler_GetPerson(t *testing.T) {
 			name: "id invalid",
 			id:   "invalid",
 			mockFunc: func(mockUCase *mocks.PersonLogic) {
-				mockUCase.On("GetOnePerson", mock.Anything, 0).Return(nil, serverErr.ErrNotFound)
+				mockUCase.On("GetOnePerson", mock.Anything, 0, []string(nil)).Return(nil, serverErr.ErrNotFound)
 			},
 			waitCode:     http.StatusNotFound,
 			waitResponse: string(jsonErrNotFound),
@@ -393,6 +413,15 @@ func TestHandler_DeletePerson(t *testing.T) {
 			waitCode:     http.StatusNotFound,
 			waitResponse: string(jsonErrNotFound),
 		},
+		{
+			name: "forbidden",
+			id:   "1",
+			mockFunc: func(mockUCase *mocks.PersonLogic) {
+				mockUCase.On("Delete", mock.Anything, testPerson.ID).Return(serverErr.ErrForbidden)
+			},
+			waitCode:     http.StatusForbidden,
+			waitResponse: string(jsonErrForbidden),
+		},
 		{
 			name: "id invalid",
 			id:   "invalid",
@@ -426,3 +455,269 @@ func TestHandler_DeletePerson(t *testing.T) {
 		mockUCase.AssertExpectations(t)
 	}
 }
+
+func TestHandler_Negotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		mockFunc        func(mockUCase *mocks.PersonLogic)
+		method          string
+		accept          string
+		contentType     string
+		data            string
+		waitCode        int
+		waitContentType string
+	}{
+		{
+			name: "xml response",
+			mockFunc: func(mockUCase *mocks.PersonLogic) {
+				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
+			},
+			method:          echo.GET,
+			accept:          echo.MIMEApplicationXML,
+			waitCode:        http.StatusOK,
+			waitContentType: echo.MIMEApplicationXMLCharsetUTF8,
+		},
+		{
+			name: "msgpack response",
+			mockFunc: func(mockUCase *mocks.PersonLogic) {
+				mockUCase.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
+			},
+			method:          echo.GET,
+			accept:          "application/msgpack",
+			waitCode:        http.StatusOK,
+			waitContentType: "application/msgpack",
+		},
+		{
+			name:            "not acceptable",
+			mockFunc:        func(mockUCase *mocks.PersonLogic) {},
+			method:          echo.PUT,
+			accept:          "text/html",
+			contentType:     echo.MIMEApplicationJSON,
+			data:            string(PersonJson),
+			waitCode:        http.StatusNotAcceptable,
+			waitContentType: echo.MIMEApplicationJSONCharsetUTF8,
+		},
+		{
+			name:            "unsupported media type",
+			mockFunc:        func(mockUCase *mocks.PersonLogic) {},
+			method:          echo.PUT,
+			accept:          "application/cbor",
+			contentType:     "text/plain",
+			data:            "test",
+			waitCode:        http.StatusUnsupportedMediaType,
+			waitContentType: "application/cbor",
+		},
+	}
+	for _, test := range tests {
+		mockUCase := new(mocks.PersonLogic)
+		test.mockFunc(mockUCase)
+
+		e := echo.New()
+		personHandler.NewHandler(e, mockUCase)
+
+		req := httptest.NewRequest(test.method, "/person/1", strings.NewReader(test.data))
+		req.Header.Set(echo.HeaderAccept, test.accept)
+		if test.contentType != "" {
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		assert.Equal(t, test.waitContentType, rec.Header().Get(echo.HeaderContentType), test.name)
+		mockUCase.AssertExpectations(t)
+	}
+}
+
+func TestHandler_GetPersonsFieldsExpand(t *testing.T) {
+	sparsePerson := &entity.Person{ID: 1, FirstName: "test"}
+	ListSparsePerson := []*entity.Person{sparsePerson}
+	one := 1
+	sparseResponse := func(query string) string {
+		data, _ := json.Marshal(personHandler.ResponseData{
+			Data:     resources(sparsePerson),
+			Total:    &one,
+			Page:     1,
+			LastPage: &one,
+			Links:    pageLinks(query, 1, 1),
+		})
+		return string(data)
+	}
+
+	tests := []struct {
+		name         string
+		mockFunc     func(mockUCase *mocks.PersonLogic, mockExpander *mocks.Expander)
+		path         string
+		waitCode     int
+		waitResponse string
+	}{
+		{
+			name: "fields",
+			mockFunc: func(mockUCase *mocks.PersonLogic, mockExpander *mocks.Expander) {
+				mockUCase.On("GetPersons", mock.Anything, "", "", "", []string{"id", "first_name"}, 0, 0, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListSparsePerson, Total: 1, Page: 1, LastPage: 1, Count: entity.CountExact}, nil)
+			},
+			path:         "/person?fields=id,%20first_name,",
+			waitCode:     http.StatusOK,
+			waitResponse: sparseResponse("fields=id,+first_name,"),
+		},
+		{
+			name: "expand",
+			mockFunc: func(mockUCase *mocks.PersonLogic, mockExpander *mocks.Expander) {
+				mockUCase.On("GetPersons", mock.Anything, "", "", "", []string(nil), 0, 0, entity.CountMode("")).Return(&entity.PersonPage{Persons: ListSparsePerson, Total: 1, Page: 1, LastPage: 1, Count: entity.CountExact}, nil)
+				mockExpander.On("Expand", mock.Anything, ListSparsePerson).Return(nil)
+			},
+			path:         "/person?expand=test",
+			waitCode:     http.StatusOK,
+			waitResponse: sparseResponse("expand=test"),
+		},
+		{
+			name: "expand error",
+			mockFunc: func(mockUCase *mocks.PersonLogic, mockExpander *mocks.Expander) {
+				mockUCase.On("GetOnePerson", mock.Anything, 1, []string(nil)).Return(sparsePerson, nil)
+				mockExpander.On("Expand", mock.Anything, ListSparsePerson).Return(serverErr.ErrInternalServer)
+			},
+			path:         "/person/1?expand=test",
+			waitCode:     http.StatusInternalServerError,
+			waitResponse: string(jsonErrServer),
+		},
+		{
+			name:         "unknown expand",
+			mockFunc:     func(mockUCase *mocks.PersonLogic, mockExpander *mocks.Expander) {},
+			path:         "/person?expand=unknown",
+			waitCode:     http.StatusBadRequest,
+			waitResponse: string(jsonErrBadParam),
+		},
+	}
+	for _, test := range tests {
+		mockUCase := new(mocks.PersonLogic)
+		mockExpander := new(mocks.Expander)
+		mockExpander.On("Name").Return("test")
+		test.mockFunc(mockUCase, mockExpander)
+
+		e := echo.New()
+		personHandler.NewHandler(e, mockUCase, mockExpander)
+
+		req := httptest.NewRequest(echo.GET, test.path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		assert.Equal(t, test.waitResponse, strings.Trim(rec.Body.String(), "\n"), test.name)
+		mockUCase.AssertExpectations(t)
+		mockExpander.AssertExpectations(t)
+	}
+}
+
+func TestHandler_GetPersonsLinks(t *testing.T) {
+	mockUCase := new(mocks.PersonLogic)
+	mockUCase.On("GetPersons", mock.Anything, "", "", "test", []string(nil), 2, 1, entity.CountMode("")).Return(&entity.PersonPage{Persons: []*entity.Person{testPerson2}, Total: 3, Page: 2, LastPage: 3, HasMore: true, Count: entity.CountExact}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/person?first_name=test&page=2&limit=1", nil)
+	req.Header.Set(echo.HeaderXForwardedProto, "https")
+	req.Header.Set(personHandler.HeaderXForwardedHost, "api.test.ru, ingress.local")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := personHandler.Handler{Logic: mockUCase}
+	err := handler.GetPersons(c)
+	require.NoError(t, err)
+
+	href := "https://api.test.ru/person?first_name=test&limit=1&page="
+	assert.Equal(t, `<`+href+`2>; rel="self", <`+href+`1>; rel="first", <`+href+`1>; rel="prev", <`+href+`3>; rel="next", <`+href+`3>; rel="last"`,
+		rec.Header().Get(personHandler.HeaderLink))
+
+	data := &personHandler.ResponseData{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), data))
+	assert.Equal(t, href+"1", data.Links.Prev.Href)
+	assert.Equal(t, href+"3", data.Links.Next.Href)
+	assert.Equal(t, "https://api.test.ru/person/2", data.Data[0].Links.Self.Href)
+	mockUCase.AssertExpectations(t)
+}
+
+func TestHandler_GetPersonsCountNone(t *testing.T) {
+	mockUCase := new(mocks.PersonLogic)
+	mockUCase.On("GetPersons", mock.Anything, "", "", "", []string(nil), 0, 1, entity.CountNone).Return(&entity.PersonPage{Persons: []*entity.Person{testPerson}, Page: 1, HasMore: true, Count: entity.CountNone}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/person?limit=1&count=none", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := personHandler.Handler{Logic: mockUCase}
+	err := handler.GetPersons(c)
+	require.NoError(t, err)
+
+	data := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &data))
+	assert.NotContains(t, data, "total")
+	assert.NotContains(t, data, "last_page")
+	assert.Equal(t, true, data["has_more"])
+	assert.NotContains(t, rec.Header().Get(personHandler.HeaderLink), `rel="last"`)
+	assert.Contains(t, rec.Header().Get(personHandler.HeaderLink), `page=2>; rel="next"`)
+	mockUCase.AssertExpectations(t)
+}
+
+func TestHandler_GetPersonNotModified(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 12, 30, 15, 500000000, time.UTC)
+	modifiedPerson := &entity.Person{ID: 1, Email: "test@test.ru", Phone: "1234", FirstName: "test", UpdatedAt: &updatedAt}
+	lastModified := "Wed, 01 May 2024 12:30:15 GMT"
+	tests := []struct {
+		name             string
+		header           map[string]string
+		waitCode         int
+		waitLastModified string
+	}{
+		{name: "unconditional", waitCode: http.StatusOK, waitLastModified: lastModified},
+		{name: "not modified", header: map[string]string{echo.HeaderIfModifiedSince: lastModified}, waitCode: http.StatusNotModified, waitLastModified: lastModified},
+		{name: "not modified since later", header: map[string]string{echo.HeaderIfModifiedSince: "Thu, 02 May 2024 00:00:00 GMT"}, waitCode: http.StatusNotModified, waitLastModified: lastModified},
+		{name: "modified", header: map[string]string{echo.HeaderIfModifiedSince: "Wed, 01 May 2024 12:30:14 GMT"}, waitCode: http.StatusOK, waitLastModified: lastModified},
+		{name: "invalid date", header: map[string]string{echo.HeaderIfModifiedSince: "yesterday"}, waitCode: http.StatusOK, waitLastModified: lastModified},
+		{name: "if-none-match wins", header: map[string]string{echo.HeaderIfModifiedSince: lastModified, "If-None-Match": `"1"`}, waitCode: http.StatusOK, waitLastModified: lastModified},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockUCase := new(mocks.PersonLogic)
+			mockUCase.On("GetOnePerson", mock.Anything, 1, []string(nil)).Return(modifiedPerson, nil)
+
+			e := echo.New()
+			req := httptest.NewRequest(echo.GET, "/person/1", nil)
+			for key, value := range test.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("person/:id")
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+			handler := personHandler.Handler{Logic: mockUCase}
+			err := handler.GetPerson(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.waitCode, rec.Code)
+			assert.Equal(t, test.waitLastModified, rec.Header().Get(echo.HeaderLastModified))
+			if test.waitCode == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			}
+			mockUCase.AssertExpectations(t)
+		})
+	}
+
+	t.Run("without modification time", func(t *testing.T) {
+		mockUCase := new(mocks.PersonLogic)
+		mockUCase.On("GetOnePerson", mock.Anything, 1, []string(nil)).Return(testPerson, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/person/1", nil)
+		req.Header.Set(echo.HeaderIfModifiedSince, lastModified)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		handler := personHandler.Handler{Logic: mockUCase}
+		require.NoError(t, handler.GetPerson(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderLastModified))
+	})
+}
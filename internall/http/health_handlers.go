@@ -0,0 +1,31 @@
+package http
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/health"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type HealthHandler struct {
+	Health *health.Health
+}
+
+// Liveness only tells that the process serves requests, dependencies are left to readiness.
+func (h *HealthHandler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, &health.Report{Status: health.StatusOK})
+}
+
+func (h *HealthHandler) Readiness(c echo.Context) error {
+	report := h.Health.Readiness(c.Request().Context())
+	code := http.StatusOK
+	if report.Status != health.StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	return c.JSON(code, report)
+}
+
+func NewHealthHandler(e *echo.Echo, health *health.Health) {
+	handler := &HealthHandler{Health: health}
+	e.GET("/healthz", handler.Liveness)
+	e.GET("/readyz", handler.Readiness)
+}
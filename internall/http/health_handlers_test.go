@@ -0,0 +1,75 @@
+package http_test
+
+import (
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/health"
+	personHandler "github.com/RomanUtolin/RESTful-CRUD/internall/http"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	durations := regexp.MustCompile(`"duration_ms":[0-9.e-]+`)
+	tests := []struct {
+		name         string
+		path         string
+		checkErr     error
+		drain        bool
+		waitCode     int
+		waitResponse string
+	}{
+		{
+			name:         "liveness",
+			path:         "/healthz",
+			checkErr:     errors.New("down"),
+			waitCode:     http.StatusOK,
+			waitResponse: `{"status":"ok"}`,
+		},
+		{
+			name:         "ready",
+			path:         "/readyz",
+			waitCode:     http.StatusOK,
+			waitResponse: `{"status":"ok","checks":{"database":{"status":"ok","duration_ms":0}}}`,
+		},
+		{
+			name:         "not ready",
+			path:         "/readyz",
+			checkErr:     errors.New("down"),
+			waitCode:     http.StatusServiceUnavailable,
+			waitResponse: `{"status":"fail","checks":{"database":{"status":"fail","error":"down","duration_ms":0}}}`,
+		},
+		{
+			name:         "draining",
+			path:         "/readyz",
+			drain:        true,
+			waitCode:     http.StatusServiceUnavailable,
+			waitResponse: `{"status":"fail","draining":true}`,
+		},
+	}
+	for _, test := range tests {
+		check := new(mocks.Check)
+		check.On("Name").Return("database").Maybe()
+		check.On("Check", mock.Anything).Return(test.checkErr).Maybe()
+		h := health.NewHealth(reload.NewValue(time.Second), check)
+		if test.drain {
+			h.Drain()
+		}
+		e := echo.New()
+		personHandler.NewHealthHandler(e, h)
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, test.path, nil))
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		body := durations.ReplaceAllString(strings.Trim(rec.Body.String(), "\n"), `"duration_ms":0`)
+		assert.Equal(t, test.waitResponse, body, test.name)
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/labstack/echo/v4"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	HeaderLink           = "Link"
+	HeaderXForwardedHost = "X-Forwarded-Host"
+)
+
+type Link struct {
+	Href string `json:"href" xml:"href,attr" msgpack:"href" cbor:"href"`
+}
+
+type Links struct {
+	Self  *Link `json:"self,omitempty" xml:"self,omitempty" msgpack:"self,omitempty" cbor:"self,omitempty"`
+	First *Link `json:"first,omitempty" xml:"first,omitempty" msgpack:"first,omitempty" cbor:"first,omitempty"`
+	Prev  *Link `json:"prev,omitempty" xml:"prev,omitempty" msgpack:"prev,omitempty" cbor:"prev,omitempty"`
+	Next  *Link `json:"next,omitempty" xml:"next,omitempty" msgpack:"next,omitempty" cbor:"next,omitempty"`
+	Last  *Link `json:"last,omitempty" xml:"last,omitempty" msgpack:"last,omitempty" cbor:"last,omitempty"`
+}
+
+type PersonResource struct {
+	*entity.Person
+	Links *Links `json:"_links,omitempty" xml:"links,omitempty" msgpack:"_links,omitempty" cbor:"_links,omitempty"`
+}
+
+// requestURL rebuilds the absolute URL the client used, honouring the X-Forwarded-Proto and
+// X-Forwarded-Host headers set by the ingress, which middleware.ForwardedHeaders keeps for trusted proxies only.
+func requestURL(c echo.Context) *url.URL {
+	req := c.Request()
+	host := req.Host
+	forwardedHost := req.Header.Get(HeaderXForwardedHost)
+	if forwardedHost != "" {
+		host = strings.TrimSpace(strings.Split(forwardedHost, ",")[0])
+	}
+	return &url.URL{
+		Scheme:   c.Scheme(),
+		Host:     host,
+		Path:     req.URL.Path,
+		RawQuery: req.URL.RawQuery,
+	}
+}
+
+func pageLink(base *url.URL, page int) *Link {
+	u := *base
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	u.RawQuery = query.Encode()
+	return &Link{Href: u.String()}
+}
+
+// paginationLinks builds the page links, the last link is left out when lastPage is unknown.
+func paginationLinks(c echo.Context, page, lastPage int, hasMore bool) *Links {
+	base := requestURL(c)
+	links := &Links{
+		Self:  pageLink(base, page),
+		First: pageLink(base, 1),
+	}
+	if lastPage > 0 {
+		links.Last = pageLink(base, lastPage)
+	}
+	if page > 1 {
+		links.Prev = pageLink(base, page-1)
+	}
+	if hasMore {
+		links.Next = pageLink(base, page+1)
+	}
+	return links
+}
+
+func personResources(c echo.Context, persons []*entity.Person) []*PersonResource {
+	base := requestURL(c)
+	resources := make([]*PersonResource, 0, len(persons))
+	for _, person := range persons {
+		self := url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/person/" + strconv.Itoa(person.ID)}
+		resources = append(resources, &PersonResource{
+			Person: person,
+			Links:  &Links{Self: &Link{Href: self.String()}},
+		})
+	}
+	return resources
+}
+
+// setLinkHeader writes the links as an RFC 8288 Link header.
+func setLinkHeader(c echo.Context, links *Links) {
+	values := make([]string, 0)
+	for _, link := range []struct {
+		rel  string
+		link *Link
+	}{
+		{"self", links.Self},
+		{"first", links.First},
+		{"prev", links.Prev},
+		{"next", links.Next},
+		{"last", links.Last},
+	} {
+		if link.link != nil {
+			values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, link.link.Href, link.rel))
+		}
+	}
+	c.Response().Header().Set(HeaderLink, strings.Join(values, ", "))
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	AuthMethodAPIKey = "api_key"
+	HeaderAPIKey     = "X-API-Key"
+)
+
+type APIKeyAuthenticator struct {
+	logic entity.APIKeyLogic
+	realm string
+}
+
+func NewAPIKeyAuthenticator(logic entity.APIKeyLogic, realm string) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{logic: logic, realm: realm}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(req *http.Request) (*auth.Principal, error) {
+	key := req.Header.Get(HeaderAPIKey)
+	if key == "" {
+		return nil, nil
+	}
+	apiKey, err := a.logic.Authenticate(req.Context(), key)
+	if errors.Is(err, serverErr.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{
+		Subject: "apikey:" + strconv.Itoa(apiKey.ID),
+		Method:  AuthMethodAPIKey,
+		Scopes:  apiKey.Scopes,
+	}, nil
+}
+
+func (a *APIKeyAuthenticator) Challenge(err error) string {
+	challenge := fmt.Sprintf(`APIKey realm="%s"`, a.realm)
+	if err != nil {
+		challenge += `, error="invalid_key"`
+	}
+	return challenge
+}
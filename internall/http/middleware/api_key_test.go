@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	mockLogic := new(mocks.APIKeyLogic)
+	mockLogic.On("Authenticate", mock.Anything, "rcrud_valid").Return(&entity.APIKey{ID: 3, Scopes: []string{"person:read"}}, nil)
+	mockLogic.On("Authenticate", mock.Anything, "rcrud_revoked").Return(nil, serverErr.ErrNotFound)
+	jwtAuth, err := middleware.NewJWTAuthenticator(middleware.JWTConfig{Algorithms: []string{"HS256"}, Secret: testSecret, Realm: "person-api"})
+	require.NoError(t, err)
+
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.Authenticate(nil, jwtAuth, middleware.NewAPIKeyAuthenticator(mockLogic, "person-api")))
+	server.GET("/person", func(c echo.Context) error {
+		principal, _ := auth.PrincipalFromContext(c.Request().Context())
+		return c.JSON(http.StatusOK, principal)
+	})
+
+	tests := []struct {
+		name          string
+		key           string
+		token         string
+		waitCode      int
+		waitChallenge []string
+		waitPrincipal *auth.Principal
+	}{
+		{
+			name:          "valid",
+			key:           "rcrud_valid",
+			waitCode:      http.StatusOK,
+			waitPrincipal: &auth.Principal{Subject: "apikey:3", Method: middleware.AuthMethodAPIKey, Scopes: []string{"person:read"}},
+		},
+		{
+			name:          "revoked",
+			key:           "rcrud_revoked",
+			waitCode:      http.StatusUnauthorized,
+			waitChallenge: []string{`APIKey realm="person-api", error="invalid_key"`},
+		},
+		{
+			name:          "jwt alongside",
+			token:         signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", testClaims("1", time.Hour)),
+			waitCode:      http.StatusOK,
+			waitPrincipal: &auth.Principal{Subject: "1", Method: middleware.AuthMethodJWT, Scopes: []string{"person:read", "person:write"}, Roles: []string{"user"}},
+		},
+		{
+			name:          "no credentials",
+			waitCode:      http.StatusUnauthorized,
+			waitChallenge: []string{`Bearer realm="person-api"`, `APIKey realm="person-api"`},
+		},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(echo.GET, "/person", nil)
+		if test.key != "" {
+			req.Header.Set(middleware.HeaderAPIKey, test.key)
+		}
+		if test.token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+test.token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		if test.waitChallenge != nil {
+			assert.Equal(t, test.waitChallenge, rec.Header().Values(echo.HeaderWWWAuthenticate), test.name)
+		}
+		if test.waitPrincipal != nil {
+			principal := &auth.Principal{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), principal))
+			assert.Equal(t, test.waitPrincipal, principal, test.name)
+		}
+	}
+	mockLogic.AssertExpectations(t)
+}
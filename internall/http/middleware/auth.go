@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type Authenticator interface {
+	// Authenticate returns a nil principal and no error when the request carries no credentials of its kind.
+	Authenticate(req *http.Request) (*auth.Principal, error)
+	// Challenge is the WWW-Authenticate value that asks for the credentials.
+	Challenge(err error) string
+}
+
+// Authenticate puts the principal found by the first authenticator that recognises the request credentials
+// into the request context and rejects the request with 401 otherwise.
+// Public routes, given as "METHOD /path" or "/path" with echo route templates, are let through as they are.
+func (m *GoMiddleware) Authenticate(publicRoutes []string, authenticators ...Authenticator) echo.MiddlewareFunc {
+	public := make(map[string]bool)
+	for _, route := range publicRoutes {
+		public[strings.TrimSpace(route)] = true
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if public[c.Path()] || public[req.Method+" "+c.Path()] {
+				return next(c)
+			}
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(req)
+				if err != nil {
+					logger.FromContext(req.Context()).WithFields(logrus.Fields{
+						"Error":  err,
+						"method": req.Method,
+						"path":   c.Path(),
+					}).Warning("authentication failed")
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, authenticator.Challenge(err))
+					return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidCredentials.Error())
+				}
+				if principal != nil {
+					ctx := auth.WithPrincipal(req.Context(), principal)
+					ctx = logger.WithFields(ctx, logrus.Fields{
+						"principal":   principal.Subject,
+						"auth_method": principal.Method,
+					})
+					c.SetRequest(req.WithContext(ctx))
+					return next(c)
+				}
+			}
+			for _, authenticator := range authenticators {
+				c.Response().Header().Add(echo.HeaderWWWAuthenticate, authenticator.Challenge(nil))
+			}
+			return echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package middleware_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func testClaims(subject string, expiresIn time.Duration) *middleware.Claims {
+	return &middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    "test-issuer",
+			Audience:  jwt.ClaimStrings{"person-api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+		Scope: "person:read person:write",
+		Roles: []string{"user"},
+	}
+}
+
+func newAuthServer(t *testing.T, cfg middleware.JWTConfig) *echo.Echo {
+	jwtAuth, err := middleware.NewJWTAuthenticator(cfg)
+	require.NoError(t, err)
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.Authenticate([]string{"GET /public"}, jwtAuth))
+	handler := func(c echo.Context) error {
+		principal, ok := auth.PrincipalFromContext(c.Request().Context())
+		if !ok {
+			return c.String(http.StatusOK, "anonymous")
+		}
+		return c.JSON(http.StatusOK, principal)
+	}
+	server.GET("/person", handler)
+	server.GET("/public", handler)
+	return server
+}
+
+func callWithToken(server *echo.Echo, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(echo.GET, path, nil)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthenticate_HS256(t *testing.T) {
+	server := newAuthServer(t, middleware.JWTConfig{
+		Algorithms: []string{"HS256"},
+		Secret:     testSecret,
+		Issuer:     "test-issuer",
+		Audience:   "person-api",
+		Realm:      "person-api",
+	})
+
+	notBefore := testClaims("1", time.Hour)
+	notBefore.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	otherAudience := testClaims("1", time.Hour)
+	otherAudience.Audience = jwt.ClaimStrings{"other"}
+	otherIssuer := testClaims("1", time.Hour)
+	otherIssuer.Issuer = "other"
+	noExpiry := testClaims("1", time.Hour)
+	noExpiry.ExpiresAt = nil
+
+	tests := []struct {
+		name          string
+		path          string
+		token         string
+		waitCode      int
+		waitChallenge string
+	}{
+		{name: "valid", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", testClaims("1", time.Hour)), waitCode: http.StatusOK},
+		{name: "missing", path: "/person", waitCode: http.StatusUnauthorized, waitChallenge: `Bearer realm="person-api"`},
+		{name: "expired", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", testClaims("1", -time.Hour)), waitCode: http.StatusUnauthorized, waitChallenge: `Bearer realm="person-api", error="invalid_token"`},
+		{name: "not before", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", notBefore), waitCode: http.StatusUnauthorized},
+		{name: "no expiry", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", noExpiry), waitCode: http.StatusUnauthorized},
+		{name: "audience", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", otherAudience), waitCode: http.StatusUnauthorized},
+		{name: "issuer", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", otherIssuer), waitCode: http.StatusUnauthorized},
+		{name: "signature", path: "/person", token: signToken(t, jwt.SigningMethodHS256, []byte("other"), "", testClaims("1", time.Hour)), waitCode: http.StatusUnauthorized},
+		{name: "algorithm", path: "/person", token: signToken(t, jwt.SigningMethodHS512, []byte(testSecret), "", testClaims("1", time.Hour)), waitCode: http.StatusUnauthorized},
+		{name: "public", path: "/public", waitCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		rec := callWithToken(server, test.path, test.token)
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		if test.waitChallenge != "" {
+			assert.Equal(t, test.waitChallenge, rec.Header().Get(echo.HeaderWWWAuthenticate), test.name)
+		}
+	}
+
+	claims := testClaims("42", time.Hour)
+	claims.PersonID = 7
+	rec := callWithToken(server, "/person", signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims))
+	principal := &auth.Principal{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), principal))
+	assert.Equal(t, &auth.Principal{
+		Subject:  "42",
+		Method:   middleware.AuthMethodJWT,
+		Scopes:   []string{"person:read", "person:write"},
+		Roles:    []string{"user"},
+		PersonID: 7,
+	}, principal)
+}
+
+func TestAuthenticate_PublicKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pemFile := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa-1",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{"kty": "OKP", "crv": "Ed25519", "kid": "ed-1", "x": base64.RawURLEncoding.EncodeToString(edPublic)},
+			{"kty": "OKP", "crv": "Ed25519", "kid": "ed-2", "x": base64.RawURLEncoding.EncodeToString(otherPublic)},
+		},
+	})
+	require.NoError(t, err)
+	jwksFile := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	server := newAuthServer(t, middleware.JWTConfig{
+		Algorithms:    []string{"RS256", "EdDSA"},
+		Secret:        testSecret,
+		PublicKeyFile: pemFile,
+		JWKSFile:      jwksFile,
+	})
+
+	tests := []struct {
+		name     string
+		token    string
+		waitCode int
+	}{
+		{name: "RS256 pem", token: signToken(t, jwt.SigningMethodRS256, rsaKey, "", testClaims("1", time.Hour)), waitCode: http.StatusOK},
+		{name: "RS256 jwks", token: signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", testClaims("1", time.Hour)), waitCode: http.StatusOK},
+		{name: "EdDSA jwks", token: signToken(t, jwt.SigningMethodEdDSA, edPrivate, "ed-1", testClaims("1", time.Hour)), waitCode: http.StatusOK},
+		{name: "EdDSA other key", token: signToken(t, jwt.SigningMethodEdDSA, edPrivate, "ed-2", testClaims("1", time.Hour)), waitCode: http.StatusUnauthorized},
+		{name: "unknown kid", token: signToken(t, jwt.SigningMethodEdDSA, edPrivate, "ed-3", testClaims("1", time.Hour)), waitCode: http.StatusUnauthorized},
+		{name: "HS256 not allowed", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", testClaims("1", time.Hour)), waitCode: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		rec := callWithToken(server, "/person", test.token)
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	CookieReadPrimaryUntil = "read_primary_until"
+	HeaderReadPrimaryUntil = "X-Read-Primary-Until"
+)
+
+// ReadConsistency lets clients read their own writes while replicas lag behind: write requests read the primary
+// and pin the reads of the client to it for window, through a cookie and a header holding the unix time
+// the pin ends, which clients without cookies send back as request header.
+func (m *GoMiddleware) ReadConsistency(window time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			switch req.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				if time.Now().Before(readPrimaryUntil(req, window)) {
+					c.SetRequest(req.WithContext(consistency.WithPrimary(req.Context())))
+				}
+				return next(c)
+			}
+			until := strconv.FormatInt(time.Now().Add(window).Unix(), 10)
+			c.SetCookie(&http.Cookie{
+				Name:     CookieReadPrimaryUntil,
+				Value:    until,
+				Path:     "/",
+				MaxAge:   int(window.Seconds()),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			c.Response().Header().Set(HeaderReadPrimaryUntil, until)
+			c.SetRequest(req.WithContext(consistency.WithPrimary(req.Context())))
+			return next(c)
+		}
+	}
+}
+
+// readPrimaryUntil is the end of the pin sent by the client. A pin ending later than a write now would
+// was not set by ReadConsistency, it is ignored so clients cannot keep reading the primary.
+func readPrimaryUntil(req *http.Request, window time.Duration) time.Time {
+	value := req.Header.Get(HeaderReadPrimaryUntil)
+	if cookie, err := req.Cookie(CookieReadPrimaryUntil); value == "" && err == nil {
+		value = cookie.Value
+	}
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || time.Unix(until, 0).After(time.Now().Add(window)) {
+		return time.Time{}
+	}
+	return time.Unix(until, 0)
+}
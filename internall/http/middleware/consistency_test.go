@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReadConsistency(t *testing.T) {
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.ReadConsistency(5 * time.Second))
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, strconv.FormatBool(consistency.PrimaryRequired(c.Request().Context())))
+	}
+	server.GET("/person", handler)
+	server.POST("/person", handler)
+
+	future := strconv.FormatInt(time.Now().Add(3*time.Second).Unix(), 10)
+	farFuture := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	tests := []struct {
+		name        string
+		method      string
+		header      string
+		cookie      string
+		waitPrimary string
+		waitPin     bool
+	}{
+		{name: "read", method: http.MethodGet, waitPrimary: "false"},
+		{name: "write", method: http.MethodPost, waitPrimary: "true", waitPin: true},
+		{name: "read with header", method: http.MethodGet, header: future, waitPrimary: "true"},
+		{name: "read with cookie", method: http.MethodGet, cookie: future, waitPrimary: "true"},
+		{name: "read with expired cookie", method: http.MethodGet, cookie: past, waitPrimary: "false"},
+		{name: "read with pin beyond the window", method: http.MethodGet, header: farFuture, waitPrimary: "false"},
+		{name: "read with cookie beyond the window", method: http.MethodGet, cookie: farFuture, waitPrimary: "false"},
+		{name: "read with invalid header", method: http.MethodGet, header: "soon", waitPrimary: "false"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "/person", nil)
+			if test.header != "" {
+				req.Header.Set(middleware.HeaderReadPrimaryUntil, test.header)
+			}
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: middleware.CookieReadPrimaryUntil, Value: test.cookie})
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			assert.Equal(t, test.waitPrimary, rec.Body.String())
+			pin := rec.Header().Get(middleware.HeaderReadPrimaryUntil)
+			assert.Equal(t, test.waitPin, pin != "")
+			if test.waitPin {
+				until, err := strconv.ParseInt(pin, 10, 64)
+				assert.NoError(t, err)
+				assert.InDelta(t, time.Now().Add(5*time.Second).Unix(), until, 1)
+				assert.Contains(t, rec.Header().Get(echo.HeaderSetCookie), middleware.CookieReadPrimaryUntil+"="+pin)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// CacheRule sets CacheControl on the responses of Route, "/path" or empty for every route.
+type CacheRule struct {
+	Route        string
+	CacheControl string
+}
+
+// HTTPCache sets the Cache-Control of the first rule matching a GET or HEAD request on its successful and
+// 304 responses, other responses are left uncacheable. vary names the request headers the responses of the
+// matched routes depend on.
+func (m *GoMiddleware) HTTPCache(rules *reload.Value[[]CacheRule], vary []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			if method != http.MethodGet && method != http.MethodHead {
+				return next(c)
+			}
+			rule, ok := matchCacheRule(c.Path(), rules.Load())
+			if !ok {
+				return next(c)
+			}
+			res := c.Response()
+			for _, header := range vary {
+				res.Header().Add(echo.HeaderVary, header)
+			}
+			res.Before(func() {
+				if res.Status == http.StatusNotModified || res.Status >= 200 && res.Status < 300 {
+					res.Header().Set(echo.HeaderCacheControl, rule.CacheControl)
+				}
+			})
+			return next(c)
+		}
+	}
+}
+
+func matchCacheRule(path string, rules []CacheRule) (CacheRule, bool) {
+	for _, rule := range rules {
+		if rule.Route == "" || rule.Route == path {
+			return rule, true
+		}
+	}
+	return CacheRule{}, false
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPCache(t *testing.T) {
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	rules := reload.NewValue([]middleware.CacheRule{
+		{Route: "/person/:id", CacheControl: "private, no-cache"},
+		{Route: "/person", CacheControl: "private, max-age=5"},
+	})
+	server.Use(middl.HTTPCache(rules, []string{echo.HeaderAccept, echo.HeaderAuthorization}))
+	server.GET("/person/:id", func(c echo.Context) error {
+		switch c.Param("id") {
+		case "304":
+			return c.NoContent(http.StatusNotModified)
+		case "404":
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	server.GET("/person", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	server.POST("/person", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+	server.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		waitCacheControl string
+		waitVary         []string
+	}{
+		{name: "person", method: http.MethodGet, path: "/person/1", waitCacheControl: "private, no-cache", waitVary: []string{echo.HeaderAccept, echo.HeaderAuthorization}},
+		{name: "not modified", method: http.MethodGet, path: "/person/304", waitCacheControl: "private, no-cache", waitVary: []string{echo.HeaderAccept, echo.HeaderAuthorization}},
+		{name: "not found", method: http.MethodGet, path: "/person/404", waitVary: []string{echo.HeaderAccept, echo.HeaderAuthorization}},
+		{name: "list", method: http.MethodGet, path: "/person", waitCacheControl: "private, max-age=5", waitVary: []string{echo.HeaderAccept, echo.HeaderAuthorization}},
+		{name: "write", method: http.MethodPost, path: "/person"},
+		{name: "no rule", method: http.MethodGet, path: "/healthz"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			assert.Equal(t, test.waitCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+			assert.Equal(t, test.waitVary, rec.Header().Values(echo.HeaderVary))
+		})
+	}
+
+	rules.Store(nil)
+	req := httptest.NewRequest(http.MethodGet, "/person/1", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get(echo.HeaderCacheControl))
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// perRequestHeaders describe the request being answered rather than the kept response, they are not replayed.
+var perRequestHeaders = []string{
+	echo.HeaderXRequestID,
+	echo.HeaderSetCookie,
+	HeaderRateLimitLimit,
+	HeaderRateLimitRemaining,
+	HeaderRateLimitReset,
+	HeaderRateLimitPolicy,
+	HeaderReadPrimaryUntil,
+}
+
+type IdempotencyRecord struct {
+	BodyHash  string
+	Done      bool
+	Status    int
+	Header    http.Header
+	Body      []byte
+	ExpiresAt time.Time
+}
+
+type IdempotencyStore interface {
+	// Reserve saves record under key unless an unexpired record exists, which is returned instead.
+	Reserve(key string, record *IdempotencyRecord) (*IdempotencyRecord, bool)
+	Complete(key string, record *IdempotencyRecord)
+	Release(key string)
+}
+
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*IdempotencyRecord
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(key string, record *IdempotencyRecord) (*IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, r := range s.records {
+			if now.After(r.ExpiresAt) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	existing, ok := s.records[key]
+	if ok && now.Before(existing.ExpiresAt) {
+		return existing, false
+	}
+	s.records[key] = record
+	return record, true
+}
+
+func (s *MemoryIdempotencyStore) Complete(key string, record *IdempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+}
+
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the first response of a POST or PATCH request to later requests
+// of the same principal carrying the same Idempotency-Key for the same route, for ttl.
+// Responses with a 5xx status are not kept, so such requests can be retried, and neither are responses marked
+// Cache-Control: no-store, such as those showing a secret once. Bodies over maxBody bytes are rejected.
+func (m *GoMiddleware) Idempotency(store IdempotencyStore, ttl time.Duration, maxBody int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			idempotencyKey := req.Header.Get(HeaderIdempotencyKey)
+			if idempotencyKey == "" || (req.Method != http.MethodPost && req.Method != http.MethodPatch) {
+				return next(c)
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxBody))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request body is too large")
+				}
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			hash := sha256.Sum256(body)
+			bodyHash := hex.EncodeToString(hash[:])
+
+			key := idempotencyKey + " " + req.Method + " " + req.URL.Path
+			principal, ok := auth.PrincipalFromContext(req.Context())
+			if ok {
+				key = principal.Subject + " " + key
+			}
+			record, reserved := store.Reserve(key, &IdempotencyRecord{
+				BodyHash:  bodyHash,
+				ExpiresAt: time.Now().Add(ttl),
+			})
+			if !reserved {
+				switch {
+				case record.BodyHash != bodyHash:
+					return echo.NewHTTPError(http.StatusUnprocessableEntity, "idempotency key was already used with another request body")
+				case !record.Done:
+					return echo.NewHTTPError(http.StatusConflict, "request with this idempotency key is in progress")
+				}
+				header := c.Response().Header()
+				for name, values := range record.Header {
+					if _, ok := header[name]; !ok {
+						header[name] = values
+					}
+				}
+				header.Set(HeaderIdempotentReplayed, "true")
+				c.Response().WriteHeader(record.Status)
+				_, err = c.Response().Write(record.Body)
+				return err
+			}
+
+			// a panicking handler must not leave the key in progress until it expires
+			completed := false
+			defer func() {
+				if !completed {
+					store.Release(key)
+				}
+			}()
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			err = next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			if c.Response().Status >= http.StatusInternalServerError || noStore(c.Response().Header()) {
+				return nil
+			}
+			completed = true
+			header := c.Response().Header().Clone()
+			for _, name := range perRequestHeaders {
+				header.Del(name)
+			}
+			store.Complete(key, &IdempotencyRecord{
+				BodyHash:  bodyHash,
+				Done:      true,
+				Status:    c.Response().Status,
+				Header:    header,
+				Body:      recorder.body.Bytes(),
+				ExpiresAt: record.ExpiresAt,
+			})
+			return nil
+		}
+	}
+}
+
+func noStore(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get(echo.HeaderCacheControl), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
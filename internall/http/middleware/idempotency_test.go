@@ -0,0 +1,164 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.Idempotency(middleware.NewMemoryIdempotencyStore(), time.Minute, 1024))
+	server.POST("/person", func(c echo.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		if c.QueryParam("wait") != "" {
+			<-release
+		}
+		c.Response().Header().Set("X-Call", strconv.Itoa(int(n)))
+		return c.String(http.StatusCreated, c.Request().Header.Get("X-Body-Id"))
+	})
+	server.POST("/fail", func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.POST, path, strings.NewReader(body))
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+		req.Header.Set("X-Body-Id", body)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("/person", "key-1", "first")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "", first.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	replay := send("/person", "key-1", "first")
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "first", replay.Body.String())
+	assert.Equal(t, "1", replay.Header().Get("X-Call"))
+	assert.Equal(t, "true", replay.Header().Get(middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	mismatch := send("/person", "key-1", "second")
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+
+	other := send("/person", "key-2", "second")
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- send("/person?wait=1", "key-3", "third")
+	}()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 3
+	}, time.Second, time.Millisecond)
+	inFlight := send("/person?wait=1", "key-3", "third")
+	assert.Equal(t, http.StatusConflict, inFlight.Code)
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+
+	assert.Equal(t, http.StatusInternalServerError, send("/fail", "key-4", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, send("/fail", "key-4", "").Code)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_NotReplayed(t *testing.T) {
+	var calls int32
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.RequestID)
+	server.Use(middl.Idempotency(middleware.NewMemoryIdempotencyStore(), time.Minute, 1024))
+	server.POST("/secret", func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+		return c.String(http.StatusCreated, "whsec_0123")
+	})
+	server.POST("/person", func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		c.Response().Header().Set(middleware.HeaderRateLimitRemaining, "9")
+		return c.NoContent(http.StatusCreated)
+	})
+
+	send := func(path, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.POST, path, strings.NewReader("{}"))
+		req.Header.Set(middleware.HeaderIdempotencyKey, "key-1")
+		req.Header.Set(echo.HeaderXRequestID, requestID)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// a secret shown once is not kept, the request runs again
+	assert.Equal(t, http.StatusCreated, send("/secret", "request-1").Code)
+	assert.Equal(t, "", send("/secret", "request-2").Header().Get(middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	send("/person", "request-3")
+	replay := send("/person", "request-4")
+	assert.Equal(t, "true", replay.Header().Get(middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, "request-4", replay.Header().Get(echo.HeaderXRequestID))
+	assert.Empty(t, replay.Header().Get(middleware.HeaderRateLimitRemaining))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	var calls int32
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.Idempotency(middleware.NewMemoryIdempotencyStore(), time.Minute, 1024))
+	server.POST("/person", func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return c.NoContent(http.StatusCreated)
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(echo.POST, "/person", strings.NewReader("{}"))
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_Limits(t *testing.T) {
+	var calls int32
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(echoMiddleware.Recover())
+	server.Use(middl.Idempotency(middleware.NewMemoryIdempotencyStore(), time.Minute, 8))
+	server.POST("/person", func(c echo.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			panic("handler failed")
+		}
+		return c.NoContent(http.StatusCreated)
+	})
+
+	send := func(body string) int {
+		req := httptest.NewRequest(echo.POST, "/person", strings.NewReader(body))
+		req.Header.Set(middleware.HeaderIdempotencyKey, "key-1")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send("a body over the limit"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	assert.Equal(t, http.StatusInternalServerError, send("{}"))
+	assert.Equal(t, http.StatusCreated, send("{}"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
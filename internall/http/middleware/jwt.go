@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const AuthMethodJWT = "jwt"
+
+type JWTConfig struct {
+	Algorithms    []string
+	Secret        string
+	PublicKeyFile string
+	JWKSFile      string
+	Issuer        string
+	Audience      string
+	Leeway        time.Duration
+	Realm         string
+}
+
+type JWTAuthenticator struct {
+	parser *jwt.Parser
+	keys   map[string]crypto.PublicKey
+	secret []byte
+	realm  string
+}
+
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	if len(cfg.Algorithms) == 0 {
+		return nil, errors.New("jwt: no algorithms configured")
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	a := &JWTAuthenticator{
+		parser: jwt.NewParser(options...),
+		keys:   make(map[string]crypto.PublicKey),
+		secret: []byte(cfg.Secret),
+		realm:  cfg.Realm,
+	}
+	if cfg.PublicKeyFile != "" {
+		key, err := loadPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys[""] = key
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		for kid, key := range keys {
+			a.keys[kid] = key
+		}
+	}
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(req *http.Request) (*auth.Principal, error) {
+	header := req.Header.Get(echo.HeaderAuthorization)
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, nil
+	}
+	claims := &Claims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), claims, a.key)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return &auth.Principal{
+		Subject:  claims.Subject,
+		Method:   AuthMethodJWT,
+		Scopes:   claims.scopes(),
+		Roles:    claims.Roles,
+		PersonID: claims.PersonID,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Challenge(err error) string {
+	challenge := fmt.Sprintf(`Bearer realm="%s"`, a.realm)
+	if err != nil {
+		challenge += `, error="invalid_token"`
+	}
+	return challenge
+}
+
+func (a *JWTAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(a.secret) == 0 {
+			return nil, errors.New("no secret for HMAC tokens")
+		}
+		return a.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// Claims are the registered claims plus the authorization claims of the person API.
+// Scopes are read from the space separated "scope" claim or the "scp" list. PersonID maps the caller
+// to the person it owns, the subject is an id of the identity provider and never one of a person.
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope    string   `json:"scope,omitempty"`
+	Scp      []string `json:"scp,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	PersonID int      `json:"person_id,omitempty"`
+}
+
+func (c *Claims) scopes() []string {
+	if len(c.Scp) > 0 {
+		return c.Scp
+	}
+	return strings.Fields(c.Scope)
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: %s is not PEM encoded", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("jwt: unsupported key type %T in %s", key, path)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+}
+
+// loadJWKS reads the RSA and Ed25519 public keys of a local JWKS file by key id.
+func loadJWKS(path string) (map[string]crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = json.Unmarshal(data, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		switch {
+		case k.Kty == "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "OKP" && k.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil {
+				return nil, err
+			}
+			if len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("jwt: invalid Ed25519 key %q", k.Kid)
+			}
+			keys[k.Kid] = ed25519.PublicKey(x)
+		}
+	}
+	return keys, nil
+}
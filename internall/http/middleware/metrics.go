@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics records the duration of every request by route template, method and status.
+// Errors are returned to the outer middleware, the status recorded is the one they will be answered with.
+func (m *GoMiddleware) Metrics(metrics *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			metrics.RequestsInFlight.Inc()
+			defer metrics.RequestsInFlight.Dec()
+			start := time.Now()
+			err := next(c)
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.RequestDuration.
+				WithLabelValues(route, c.Request().Method, strconv.Itoa(status)).
+				Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
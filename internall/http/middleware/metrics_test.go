@@ -0,0 +1,56 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	var errs []error
+	server.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			errs = append(errs, err)
+			return err
+		}
+	})
+	server.Use(middl.Metrics(m))
+	server.GET("/person/:id", func(c echo.Context) error {
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.RequestsInFlight))
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/person/1", "/person/2", "/person/0", "/unknown"} {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(echo.GET, path, nil))
+	}
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.RequestsInFlight))
+	// the errors reach the outer middleware
+	assert.Equal(t, []error{nil, nil, echo.NewHTTPError(http.StatusNotFound), echo.ErrNotFound}, errs)
+	assert.Equal(t, 3, testutil.CollectAndCount(m.RequestDuration))
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(echo.GET, "/metrics", nil))
+	body := rec.Body.String()
+	for _, series := range []string{
+		`person_api_http_request_duration_seconds_count{method="GET",route="/person/:id",status="200"} 2`,
+		`person_api_http_request_duration_seconds_count{method="GET",route="/person/:id",status="404"} 1`,
+		`person_api_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		require.True(t, strings.Contains(body, series), series)
+	}
+}
@@ -1,26 +1,107 @@
 package middleware
 
 import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type GoMiddleware struct {
 }
 
-func (m *GoMiddleware) CORS(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		c.Response().Header().Set("Access-Control-Allow-Origin", "*")
-		return next(c)
+// CORSConfig lists what cross-origin browser clients may do. AllowOrigins holds exact origins, "*"
+// or patterns with one wildcard for subdomains such as "https://*.example.com", "*" never with credentials.
+// Empty AllowHeaders allow the headers the preflight asks for.
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+func (m *GoMiddleware) CORS(config *reload.Value[CORSConfig]) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cfg := config.Load()
+			req := c.Request()
+			header := c.Response().Header()
+			origin := req.Header.Get(echo.HeaderOrigin)
+			preflight := req.Method == http.MethodOptions && req.Header.Get(echo.HeaderAccessControlRequestMethod) != ""
+			header.Add(echo.HeaderVary, echo.HeaderOrigin)
+			if preflight {
+				header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+				header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+			}
+			allowOrigin := matchOrigin(origin, cfg.AllowOrigins)
+			if origin == "" || allowOrigin == "" {
+				if preflight {
+					return c.NoContent(http.StatusNoContent)
+				}
+				return next(c)
+			}
+			header.Set(echo.HeaderAccessControlAllowOrigin, allowOrigin)
+			// browsers refuse credentials for "*", which the configuration validation rejects as well
+			if cfg.AllowCredentials && allowOrigin != "*" {
+				header.Set(echo.HeaderAccessControlAllowCredentials, "true")
+			}
+			if !preflight {
+				if len(cfg.ExposeHeaders) > 0 {
+					header.Set(echo.HeaderAccessControlExposeHeaders, strings.Join(cfg.ExposeHeaders, ", "))
+				}
+				return next(c)
+			}
+			allowMethods := cfg.AllowMethods
+			if len(allowMethods) == 0 {
+				allowMethods = defaultCORSMethods
+			}
+			header.Set(echo.HeaderAccessControlAllowMethods, strings.Join(allowMethods, ", "))
+			if len(cfg.AllowHeaders) > 0 {
+				header.Set(echo.HeaderAccessControlAllowHeaders, strings.Join(cfg.AllowHeaders, ", "))
+			} else if requested := req.Header.Get(echo.HeaderAccessControlRequestHeaders); requested != "" {
+				header.Set(echo.HeaderAccessControlAllowHeaders, requested)
+			}
+			if cfg.MaxAge > 0 {
+				header.Set(echo.HeaderAccessControlMaxAge, strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			return c.NoContent(http.StatusNoContent)
+		}
+	}
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for origin or "" when it is not allowed.
+func matchOrigin(origin string, allowOrigins []string) string {
+	for _, allowed := range allowOrigins {
+		switch {
+		case allowed == "*":
+			return "*"
+		case strings.EqualFold(allowed, origin):
+			return origin
+		case strings.Count(allowed, "*") == 1:
+			prefix, suffix, _ := strings.Cut(strings.ToLower(allowed), "*")
+			lower := strings.ToLower(origin)
+			if len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) &&
+				!strings.Contains(lower[len(prefix):len(lower)-len(suffix)], "/") {
+				return origin
+			}
+		}
 	}
+	return ""
 }
 
 func (m *GoMiddleware) LogRequest(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		start := time.Now()
 		c.Response().After(func() {
-			logrus.WithFields(logrus.Fields{
+			logger.FromContext(c.Request().Context()).WithFields(logrus.Fields{
 				"method":     c.Request().Method,
 				"path":       c.Path(),
 				"code":       c.Response().Status,
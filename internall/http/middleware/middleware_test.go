@@ -1,23 +1,32 @@
 package middleware_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
 	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCORS(t *testing.T) {
 	server := echo.New()
 	req := httptest.NewRequest(echo.GET, "/", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://app.example.com")
 	rec := httptest.NewRecorder()
 	ctx := server.NewContext(req, rec)
 	middl := middleware.InitMiddleware()
 
-	handler := middl.CORS(echo.HandlerFunc(func(c echo.Context) error {
+	handler := middl.CORS(reload.NewValue(middleware.CORSConfig{AllowOrigins: []string{"*"}}))(echo.HandlerFunc(func(c echo.Context) error {
 		return c.NoContent(http.StatusOK)
 	}))
 
@@ -25,3 +34,177 @@ func TestCORS(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
 }
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := middleware.CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowMethods:     []string{"GET", "PUT", "DELETE"},
+		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		ExposeHeaders:    []string{"ETag", "Link"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	tests := []struct {
+		name             string
+		method           string
+		origin           string
+		requestMethod    string
+		waitCode         int
+		waitAllowOrigin  string
+		waitAllowMethods string
+		waitAllowHeaders string
+		waitExpose       string
+		waitMaxAge       string
+	}{
+		{
+			name:             "preflight exact origin",
+			method:           echo.OPTIONS,
+			origin:           "https://app.example.com",
+			requestMethod:    "DELETE",
+			waitCode:         http.StatusNoContent,
+			waitAllowOrigin:  "https://app.example.com",
+			waitAllowMethods: "GET, PUT, DELETE",
+			waitAllowHeaders: "Authorization, Content-Type",
+			waitMaxAge:       "600",
+		},
+		{
+			name:             "preflight subdomain",
+			method:           echo.OPTIONS,
+			origin:           "https://api.eu.example.org",
+			requestMethod:    "PUT",
+			waitCode:         http.StatusNoContent,
+			waitAllowOrigin:  "https://api.eu.example.org",
+			waitAllowMethods: "GET, PUT, DELETE",
+			waitAllowHeaders: "Authorization, Content-Type",
+			waitMaxAge:       "600",
+		},
+		{
+			name:          "preflight bare domain does not match pattern",
+			method:        echo.OPTIONS,
+			origin:        "https://example.org",
+			requestMethod: "PUT",
+			waitCode:      http.StatusNoContent,
+		},
+		{
+			name:          "preflight other origin",
+			method:        echo.OPTIONS,
+			origin:        "https://evil.com",
+			requestMethod: "PUT",
+			waitCode:      http.StatusNoContent,
+		},
+		{
+			name:            "simple request",
+			method:          echo.GET,
+			origin:          "https://app.example.com",
+			waitCode:        http.StatusOK,
+			waitAllowOrigin: "https://app.example.com",
+			waitExpose:      "ETag, Link",
+		},
+		{
+			name:     "simple request other origin",
+			method:   echo.GET,
+			origin:   "https://evil.com",
+			waitCode: http.StatusOK,
+		},
+		{
+			name:     "same origin",
+			method:   echo.GET,
+			waitCode: http.StatusOK,
+		},
+	}
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.CORS(reload.NewValue(cfg)))
+	server.GET("/person", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	server.DELETE("/person", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/person", nil)
+		if test.origin != "" {
+			req.Header.Set(echo.HeaderOrigin, test.origin)
+		}
+		if test.requestMethod != "" {
+			req.Header.Set(echo.HeaderAccessControlRequestMethod, test.requestMethod)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		header := rec.Header()
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		assert.Equal(t, test.waitAllowOrigin, header.Get(echo.HeaderAccessControlAllowOrigin), test.name)
+		assert.Equal(t, test.waitAllowMethods, header.Get(echo.HeaderAccessControlAllowMethods), test.name)
+		assert.Equal(t, test.waitAllowHeaders, header.Get(echo.HeaderAccessControlAllowHeaders), test.name)
+		assert.Equal(t, test.waitExpose, header.Get(echo.HeaderAccessControlExposeHeaders), test.name)
+		assert.Equal(t, test.waitMaxAge, header.Get(echo.HeaderAccessControlMaxAge), test.name)
+		if test.waitAllowOrigin != "" {
+			assert.Equal(t, "true", header.Get(echo.HeaderAccessControlAllowCredentials), test.name)
+		}
+		assert.Contains(t, header.Values(echo.HeaderVary), echo.HeaderOrigin, test.name)
+	}
+}
+
+func TestCORS_WildcardWithCredentials(t *testing.T) {
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.CORS(reload.NewValue(middleware.CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})))
+	server.PUT("/person/:id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	req := httptest.NewRequest(echo.OPTIONS, "/person/1", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://app.example.com")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, "PUT")
+	req.Header.Set(echo.HeaderAccessControlRequestHeaders, "X-API-Key")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowCredentials))
+	assert.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+	assert.Equal(t, "X-API-Key", rec.Header().Get(echo.HeaderAccessControlAllowHeaders))
+}
+
+func TestRequestID(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.RequestID)
+	server.GET("/person/:id", func(c echo.Context) error {
+		logger.FromContext(c.Request().Context()).Info("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		waitKept  bool
+	}{
+		{name: "kept", requestID: "client-id-1", waitKept: true},
+		{name: "generated", requestID: ""},
+		{name: "invalid", requestID: "with space"},
+		{name: "too long", requestID: strings.Repeat("a", 129)},
+	}
+	for _, test := range tests {
+		out.Reset()
+		req := httptest.NewRequest(echo.GET, "/person/1", nil)
+		if test.requestID != "" {
+			req.Header.Set(echo.HeaderXRequestID, test.requestID)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(echo.HeaderXRequestID)
+		if test.waitKept {
+			assert.Equal(t, test.requestID, id, test.name)
+		} else {
+			assert.Len(t, id, 32, test.name)
+		}
+		line := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &line), test.name)
+		assert.Equal(t, id, line["request_id"], test.name)
+		assert.Equal(t, "/person/:id", line["route"], test.name)
+	}
+}
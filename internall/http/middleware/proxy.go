@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net"
+)
+
+// forwardedHeaders are the headers a proxy sets about the original request.
+var forwardedHeaders = []string{
+	echo.HeaderXForwardedFor,
+	echo.HeaderXForwardedProto,
+	echo.HeaderXForwardedProtocol,
+	echo.HeaderXForwardedSsl,
+	echo.HeaderXUrlScheme,
+	echo.HeaderXRealIP,
+	"X-Forwarded-Host",
+	"Forwarded",
+}
+
+// TrustedProxies are the networks of the proxies whose forwarded headers are believed.
+type TrustedProxies []*net.IPNet
+
+func ParseTrustedProxies(cidrs []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", cidr, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) Contains(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPExtractor takes the client IP from the X-Forwarded-For entries added by the trusted proxies, from the peer
+// address otherwise, so that clients cannot choose the IP they are limited by.
+func (p TrustedProxies) IPExtractor() echo.IPExtractor {
+	if len(p) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, network := range p {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// ForwardedHeaders drops the forwarded headers of requests that do not come from a trusted proxy,
+// so the scheme and host the handlers build URLs from cannot be set by clients.
+func (m *GoMiddleware) ForwardedHeaders(proxies TrustedProxies) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			host, _, err := net.SplitHostPort(req.RemoteAddr)
+			if err != nil {
+				host = req.RemoteAddr
+			}
+			if !proxies.Contains(net.ParseIP(host)) {
+				for _, name := range forwardedHeaders {
+					req.Header.Del(name)
+				}
+			}
+			return next(c)
+		}
+	}
+}
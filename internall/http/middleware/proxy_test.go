@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForwardedHeaders(t *testing.T) {
+	proxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.ForwardedHeaders(proxies))
+	server.GET("/person", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Scheme()+" "+c.Request().Header.Get("X-Forwarded-Host"))
+	})
+	tests := []struct {
+		name       string
+		remoteAddr string
+		waitBody   string
+	}{
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:4567", waitBody: "https api.test.ru"},
+		{name: "trusted ipv6 proxy", remoteAddr: "[fd00::1]:4567", waitBody: "https api.test.ru"},
+		{name: "client", remoteAddr: "203.0.113.7:4567", waitBody: "http "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.GET, "/person", nil)
+			req.RemoteAddr = test.remoteAddr
+			req.Header.Set(echo.HeaderXForwardedProto, "https")
+			req.Header.Set("X-Forwarded-Host", "api.test.ru")
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			assert.Equal(t, test.waitBody, rec.Body.String())
+		})
+	}
+
+	_, err = middleware.ParseTrustedProxies([]string{"10.0.0.1"})
+	assert.Error(t, err)
+}
+
+func TestTrustedProxies_IPExtractor(t *testing.T) {
+	proxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	tests := []struct {
+		name          string
+		proxies       middleware.TrustedProxies
+		remoteAddr    string
+		xForwardedFor string
+		waitIP        string
+	}{
+		{name: "no proxies", remoteAddr: "203.0.113.7:4567", xForwardedFor: "198.51.100.1", waitIP: "203.0.113.7"},
+		{name: "through proxy", proxies: proxies, remoteAddr: "10.1.2.3:4567", xForwardedFor: "198.51.100.1", waitIP: "198.51.100.1"},
+		{name: "spoofed entry", proxies: proxies, remoteAddr: "10.1.2.3:4567", xForwardedFor: "1.2.3.4, 198.51.100.1", waitIP: "198.51.100.1"},
+		{name: "untrusted peer", proxies: proxies, remoteAddr: "192.168.1.5:4567", xForwardedFor: "198.51.100.1", waitIP: "192.168.1.5"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.GET, "/person", nil)
+			req.RemoteAddr = test.remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, test.xForwardedFor)
+			assert.Equal(t, test.waitIP, test.proxies.IPExtractor()(req))
+		})
+	}
+}
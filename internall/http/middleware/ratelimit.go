@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimitRule allows Requests per Period to every client of Tier on Route.
+// Route is "METHOD /path", "/path" or empty for every route, Tier a role or scope of the principal
+// or empty for every client, anonymous ones included.
+type RateLimitRule struct {
+	Route    string
+	Tier     string
+	Requests int
+	Period   time.Duration
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token when the request is not allowed.
+	RetryAfter time.Duration
+}
+
+type RateLimitStore interface {
+	// Take removes a token from the bucket under key, which starts full with the capacity of rule.
+	Take(key string, rule RateLimitRule) RateLimitResult
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration
+}
+
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, rule RateLimitRule) RateLimitResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		// a bucket idle for a whole period is full and the same as a missing one
+		for k, b := range s.buckets {
+			if now.Sub(b.last) > b.idle {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	capacity := float64(rule.Requests)
+	rate := capacity / rule.Period.Seconds()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now, idle: rule.Period}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / rate)
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimit applies the first rule matching the route and the client, keyed by the principal or the client IP.
+// Requests no rule matches are not limited.
+// The rules are read on every request, after a reload clients keep the tokens left in their buckets up to the new capacity.
+func (m *GoMiddleware) RateLimit(store RateLimitStore, ruleSet *reload.Value[[]RateLimitRule]) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			rules := ruleSet.Load()
+			principal, _ := auth.PrincipalFromContext(c.Request().Context())
+			index := matchRule(c, principal, rules)
+			if index < 0 {
+				return next(c)
+			}
+			client := "ip:" + c.RealIP()
+			if principal != nil {
+				client = principal.Method + ":" + principal.Subject
+			}
+			err := take(c, store, strconv.Itoa(index)+"|"+client, client, rules[index])
+			if err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// IPRateLimit limits every request by the client IP alone. It runs before the authentication, so that
+// requests with invalid credentials are limited too and cannot make it look up every key they try.
+// Route and Tier of the rule are ignored.
+func (m *GoMiddleware) IPRateLimit(store RateLimitStore, rule *reload.Value[RateLimitRule]) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			client := "ip:" + c.RealIP()
+			err := take(c, store, "any|"+client, client, rule.Load())
+			if err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// take removes a token of the bucket under key and writes the rate limit headers of rule.
+func take(c echo.Context, store RateLimitStore, key, client string, rule RateLimitRule) error {
+	result := store.Take(key, rule)
+	header := c.Response().Header()
+	header.Set(HeaderRateLimitLimit, strconv.Itoa(rule.Requests))
+	header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	header.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+	header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", rule.Requests, int(rule.Period.Seconds())))
+	if !result.Allowed {
+		logger.FromContext(c.Request().Context()).WithFields(logrus.Fields{
+			"client": client,
+			"method": c.Request().Method,
+			"path":   c.Path(),
+		}).Warning("rate limit exceeded")
+		header.Set(echo.HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+		return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+	}
+	return nil
+}
+
+func matchRule(c echo.Context, principal *auth.Principal, rules []RateLimitRule) int {
+	for i, rule := range rules {
+		if rule.Route != "" && rule.Route != c.Path() && rule.Route != c.Request().Method+" "+c.Path() {
+			continue
+		}
+		if rule.Tier != "" && (principal == nil || !(principal.HasRole(rule.Tier) || principal.HasScope(rule.Tier))) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
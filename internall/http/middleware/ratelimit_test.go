@@ -0,0 +1,114 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := middleware.NewMemoryRateLimitStore()
+	rule := middleware.RateLimitRule{Requests: 2, Period: time.Hour}
+
+	first := store.Take("a", rule)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+	second := store.Take("a", rule)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+	third := store.Take("a", rule)
+	assert.False(t, third.Allowed)
+	assert.InDelta(t, (30 * time.Minute).Seconds(), third.RetryAfter.Seconds(), 1)
+	assert.InDelta(t, time.Hour.Seconds(), third.Reset.Seconds(), 1)
+
+	assert.True(t, store.Take("b", rule).Allowed)
+}
+
+func TestRateLimit(t *testing.T) {
+	premium := &auth.Principal{Subject: "1", Method: middleware.AuthMethodJWT, Roles: []string{"premium"}}
+	basic := &auth.Principal{Subject: "2", Method: middleware.AuthMethodJWT}
+	rules := []middleware.RateLimitRule{
+		{Route: "GET /person", Tier: "premium", Requests: 3, Period: time.Minute},
+		{Route: "GET /person", Requests: 1, Period: time.Minute},
+	}
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if principal := map[string]*auth.Principal{"premium": premium, "basic": basic}[c.Request().Header.Get("X-Test-Client")]; principal != nil {
+				c.SetRequest(c.Request().WithContext(auth.WithPrincipal(c.Request().Context(), principal)))
+			}
+			return next(c)
+		}
+	})
+	server.Use(middl.RateLimit(middleware.NewMemoryRateLimitStore(), reload.NewValue(rules)))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	server.GET("/person", ok)
+	server.POST("/person", ok)
+
+	call := func(method, client, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/person", nil)
+		req.Header.Set("X-Test-Client", client)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		client        string
+		ip            string
+		waitCode      int
+		waitRemaining string
+	}{
+		{name: "premium 1", method: echo.GET, client: "premium", ip: "10.0.0.1", waitCode: http.StatusOK, waitRemaining: "2"},
+		{name: "premium 2", method: echo.GET, client: "premium", ip: "10.0.0.1", waitCode: http.StatusOK, waitRemaining: "1"},
+		{name: "basic 1", method: echo.GET, client: "basic", ip: "10.0.0.1", waitCode: http.StatusOK, waitRemaining: "0"},
+		{name: "basic 2", method: echo.GET, client: "basic", ip: "10.0.0.1", waitCode: http.StatusTooManyRequests, waitRemaining: "0"},
+		{name: "anonymous ip 1", method: echo.GET, ip: "10.0.0.2", waitCode: http.StatusOK, waitRemaining: "0"},
+		{name: "anonymous other ip", method: echo.GET, ip: "10.0.0.3", waitCode: http.StatusOK, waitRemaining: "0"},
+		{name: "anonymous ip 2", method: echo.GET, ip: "10.0.0.2", waitCode: http.StatusTooManyRequests, waitRemaining: "0"},
+		{name: "no rule", method: echo.POST, client: "basic", ip: "10.0.0.1", waitCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		rec := call(test.method, test.client, test.ip)
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		assert.Equal(t, test.waitRemaining, rec.Header().Get(middleware.HeaderRateLimitRemaining), test.name)
+		if test.waitCode == http.StatusTooManyRequests {
+			assert.Equal(t, "60", rec.Header().Get(echo.HeaderRetryAfter), test.name)
+			assert.Equal(t, "1;w=60", rec.Header().Get(middleware.HeaderRateLimitPolicy), test.name)
+		}
+	}
+}
+
+func TestIPRateLimit(t *testing.T) {
+	rule := middleware.RateLimitRule{Requests: 1, Period: time.Minute}
+	server := echo.New()
+	server.IPExtractor = echo.ExtractIPDirect()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.IPRateLimit(middleware.NewMemoryRateLimitStore(), reload.NewValue(rule)))
+	server.GET("/person", func(c echo.Context) error {
+		return echo.ErrUnauthorized
+	})
+
+	call := func(ip, xForwardedFor string) int {
+		req := httptest.NewRequest(echo.GET, "/person", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set(echo.HeaderXForwardedFor, xForwardedFor)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("10.0.0.1", "1.1.1.1"))
+	assert.Equal(t, http.StatusTooManyRequests, call("10.0.0.1", "2.2.2.2"))
+	assert.Equal(t, http.StatusUnauthorized, call("10.0.0.2", "2.2.2.2"))
+}
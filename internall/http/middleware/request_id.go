@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID keeps a well-formed X-Request-ID of the request or generates one, echoes it in the response
+// and puts it with the route into the context logger.
+func (m *GoMiddleware) RequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if !isRequestIDValid(id) {
+			id = newRequestID()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		ctx := logger.WithRequestID(req.Context(), id)
+		ctx = logger.WithFields(ctx, logrus.Fields{
+			"method": req.Method,
+			"route":  c.Path(),
+		})
+		c.SetRequest(req.WithContext(ctx))
+		return next(c)
+	}
+}
+
+func isRequestIDValid(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Trace starts the server span of the request, continuing the trace of an incoming traceparent header,
+// and adds its trace id to the context logger.
+func (m *GoMiddleware) Trace(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		route := c.Path()
+		name := req.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(req.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.URL.Path),
+			))
+		defer span.End()
+		if span.SpanContext().IsValid() {
+			ctx = logger.WithFields(ctx, logrus.Fields{"trace_id": span.SpanContext().TraceID().String()})
+		}
+		if id := logger.RequestIDFromContext(ctx); id != "" {
+			span.SetAttributes(attribute.String("http.request_id", id))
+		}
+		c.SetRequest(req.WithContext(ctx))
+
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+		status := c.Response().Status
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	server := echo.New()
+	middl := middleware.InitMiddleware()
+	server.Use(middl.RequestID)
+	server.Use(middl.Trace)
+	server.GET("/person/:id", func(c echo.Context) error {
+		_, span := tracing.Tracer().Start(c.Request().Context(), "child")
+		span.End()
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusInternalServerError)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(echo.GET, "/person/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	server.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	child, root := spans[0], spans[1]
+	assert.Equal(t, "GET /person/:id", root.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", root.Parent.SpanID().String())
+	assert.Equal(t, root.SpanContext.SpanID(), child.Parent.SpanID())
+	assert.Contains(t, root.Attributes, attribute.Int("http.status_code", http.StatusOK))
+	assert.Contains(t, root.Attributes, attribute.String("http.request_id", "req-1"))
+
+	exporter.Reset()
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(echo.GET, "/person/0", nil))
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.False(t, spans[1].Parent.IsValid())
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Contains(t, spans[1].Attributes, attribute.Int("http.status_code", http.StatusInternalServerError))
+}
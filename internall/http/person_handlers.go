@@ -1,28 +1,43 @@
 package http
 
 import (
+	"context"
+	"encoding/xml"
 	"errors"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
 	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/render"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type Handler struct {
-	Logic entity.PersonLogic
+	Logic     entity.PersonLogic
+	Expanders map[string]Expander
+}
+
+// Expander loads a related sub-resource requested with ?expand= into the persons.
+type Expander interface {
+	Name() string
+	Expand(ctx context.Context, persons []*entity.Person) error
 }
 
 type ResponseError struct {
-	Message string `json:"message"`
+	XMLName xml.Name `json:"-" xml:"error" msgpack:"-" cbor:"-"`
+	Message string   `json:"message" xml:"message" msgpack:"message" cbor:"message"`
 }
 
 type ResponseData struct {
-	Data     []*entity.Person `json:"data"`
-	Total    int              `json:"total"`
-	Page     int              `json:"page"`
-	LastPage int              `json:"last_page"`
+	XMLName  xml.Name          `json:"-" xml:"response" msgpack:"-" cbor:"-"`
+	Data     []*PersonResource `json:"data" xml:"data>person" msgpack:"data" cbor:"data"`
+	Total    *int              `json:"total,omitempty" xml:"total,omitempty" msgpack:"total,omitempty" cbor:"total,omitempty"`
+	Page     int               `json:"page" xml:"page" msgpack:"page" cbor:"page"`
+	LastPage *int              `json:"last_page,omitempty" xml:"last_page,omitempty" msgpack:"last_page,omitempty" cbor:"last_page,omitempty"`
+	HasMore  bool              `json:"has_more" xml:"has_more" msgpack:"has_more" cbor:"has_more"`
+	Links    *Links            `json:"_links" xml:"links" msgpack:"_links" cbor:"_links"`
 }
 
 func (h *Handler) GetPersons(c echo.Context) error {
@@ -30,38 +45,67 @@ func (h *Handler) GetPersons(c echo.Context) error {
 	email := c.QueryParam("email")
 	phone := c.QueryParam("phone")
 	firstName := c.QueryParam("first_name")
+	fields := splitParam(c.QueryParam("fields"))
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	count := entity.CountMode(c.QueryParam("count"))
+	expanders, err := h.getExpanders(ctx, splitParam(c.QueryParam("expand")))
+	if err != nil {
+		return getError(c, err)
+	}
 
-	persons, count, page, lastPage, err := h.Logic.GetPersons(ctx, email, phone, firstName, page, limit)
+	result, err := h.Logic.GetPersons(ctx, email, phone, firstName, fields, page, limit, count)
+	if err != nil {
+		return getError(c, err)
+	}
+	err = expand(ctx, expanders, result.Persons)
 	if err != nil {
 		return getError(c, err)
 	}
 	data := &ResponseData{
-		Data:     persons,
-		Total:    count,
-		Page:     page,
-		LastPage: lastPage,
+		Data:    personResources(c, result.Persons),
+		Page:    result.Page,
+		HasMore: result.HasMore,
+		Links:   paginationLinks(c, result.Page, result.LastPage, result.HasMore),
 	}
-	logrus.Info("Get Persons Successful")
-	return c.JSON(http.StatusOK, data)
+	if result.Count != entity.CountNone {
+		data.Total = &result.Total
+		data.LastPage = &result.LastPage
+	}
+	setLinkHeader(c, data.Links)
+	logger.FromContext(ctx).Info("Get Persons Successful")
+	return render.Respond(c, http.StatusOK, data)
 }
 
 func (h *Handler) GetPerson(c echo.Context) error {
 	ctx := c.Request().Context()
 	id, _ := strconv.Atoi(c.Param("id"))
-	person, err := h.Logic.GetOnePerson(ctx, id)
+	fields := splitParam(c.QueryParam("fields"))
+	expanders, err := h.getExpanders(ctx, splitParam(c.QueryParam("expand")))
+	if err != nil {
+		return getError(c, err)
+	}
+	person, err := h.Logic.GetOnePerson(ctx, id, fields)
 	if err != nil {
 		return getError(c, err)
 	}
-	logrus.Infof("Get person id = %v Successful", id)
-	return c.JSON(http.StatusOK, person)
+	// expanded sub-resources change on their own, only a person alone has a modification time
+	if len(expanders) == 0 && person.UpdatedAt != nil && notModified(c, *person.UpdatedAt) {
+		logger.FromContext(ctx).Infof("Get person id = %v Not Modified", id)
+		return c.NoContent(http.StatusNotModified)
+	}
+	err = expand(ctx, expanders, []*entity.Person{person})
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(ctx).Infof("Get person id = %v Successful", id)
+	return render.Respond(c, http.StatusOK, person)
 }
 
 func (h *Handler) CreatePerson(c echo.Context) error {
 	ctx := c.Request().Context()
 	req := &entity.Person{}
-	err := c.Bind(req)
+	err := render.Bind(c, req)
 	if err != nil {
 		return getError(c, err)
 	}
@@ -69,15 +113,15 @@ func (h *Handler) CreatePerson(c echo.Context) error {
 	if err != nil {
 		return getError(c, err)
 	}
-	logrus.Infof("Create person id = %v Successful", person.ID)
-	return c.JSON(http.StatusCreated, person)
+	logger.FromContext(ctx).Infof("Create person id = %v Successful", person.ID)
+	return render.Respond(c, http.StatusCreated, person)
 }
 
 func (h *Handler) UpdatePerson(c echo.Context) error {
 	ctx := c.Request().Context()
 	id, _ := strconv.Atoi(c.Param("id"))
 	req := &entity.Person{}
-	err := c.Bind(req)
+	err := render.Bind(c, req)
 	if err != nil {
 		return getError(c, err)
 	}
@@ -85,8 +129,8 @@ func (h *Handler) UpdatePerson(c echo.Context) error {
 	if err != nil {
 		return getError(c, err)
 	}
-	logrus.Infof("Update person id = %v Successful", id)
-	return c.JSON(http.StatusCreated, person)
+	logger.FromContext(ctx).Infof("Update person id = %v Successful", id)
+	return render.Respond(c, http.StatusCreated, person)
 }
 
 func (h *Handler) DeletePerson(c echo.Context) error {
@@ -96,22 +140,69 @@ func (h *Handler) DeletePerson(c echo.Context) error {
 	if err != nil {
 		return getError(c, err)
 	}
-	logrus.Infof("Delete person id = %v Successful", id)
+	logger.FromContext(ctx).Infof("Delete person id = %v Successful", id)
 	return c.NoContent(http.StatusNoContent)
 }
 
-func NewHandler(e *echo.Echo, logic entity.PersonLogic) {
-	handler := &Handler{Logic: logic}
-	e.GET("/person", handler.GetPersons)
-	e.GET("/person/:id", handler.GetPerson)
-	e.POST("/person", handler.CreatePerson)
-	e.PUT("/person/:id", handler.UpdatePerson)
-	e.DELETE("/person/:id", handler.DeletePerson)
+func NewHandler(e *echo.Echo, logic entity.PersonLogic, expanders ...Expander) {
+	handler := &Handler{Logic: logic, Expanders: make(map[string]Expander)}
+	for _, expander := range expanders {
+		handler.Expanders[expander.Name()] = expander
+	}
+	e.GET("/person", handler.GetPersons, negotiate)
+	e.GET("/person/:id", handler.GetPerson, negotiate)
+	e.POST("/person", handler.CreatePerson, negotiate)
+	e.PUT("/person/:id", handler.UpdatePerson, negotiate)
+	e.DELETE("/person/:id", handler.DeletePerson, negotiate)
+}
+
+func (h *Handler) getExpanders(ctx context.Context, names []string) ([]Expander, error) {
+	expanders := make([]Expander, 0, len(names))
+	for _, name := range names {
+		expander, ok := h.Expanders[name]
+		if !ok {
+			logger.FromContext(ctx).WithField("Expand", name).Error("unknown expand")
+			return nil, serverErr.ErrBadParamInput
+		}
+		expanders = append(expanders, expander)
+	}
+	return expanders, nil
+}
+
+func expand(ctx context.Context, expanders []Expander, persons []*entity.Person) error {
+	for _, expander := range expanders {
+		err := expander.Expand(ctx, persons)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitParam(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func negotiate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := render.Default.Select(c)
+		if err != nil {
+			return getError(c, err)
+		}
+		return next(c)
+	}
 }
 
 func getError(c echo.Context, err error) error {
 	var code int
-	logrus.Error(err)
+	logger.FromContext(c.Request().Context()).Error(err)
 	switch {
 	case errors.Is(err, serverErr.ErrBadParamInput):
 		code = http.StatusBadRequest
@@ -119,8 +210,14 @@ func getError(c echo.Context, err error) error {
 		code = http.StatusNotFound
 	case errors.Is(err, serverErr.ErrConflict):
 		code = http.StatusConflict
+	case errors.Is(err, serverErr.ErrForbidden):
+		code = http.StatusForbidden
+	case errors.Is(err, serverErr.ErrNotAcceptable):
+		code = http.StatusNotAcceptable
+	case errors.Is(err, serverErr.ErrUnsupportedMediaType):
+		code = http.StatusUnsupportedMediaType
 	default:
 		code = http.StatusInternalServerError
 	}
-	return c.JSON(code, ResponseError{Message: err.Error()})
+	return render.Respond(c, code, ResponseError{Message: err.Error()})
 }
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	MIMEApplicationMsgPack = "application/msgpack"
+	MIMEApplicationCBOR    = "application/cbor"
+)
+
+type JSON struct{}
+
+func (JSON) ContentType() string {
+	return echo.MIMEApplicationJSONCharsetUTF8
+}
+
+func (JSON) MediaTypes() []string {
+	return []string{echo.MIMEApplicationJSON}
+}
+
+func (JSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type XML struct{}
+
+func (XML) ContentType() string {
+	return echo.MIMEApplicationXMLCharsetUTF8
+}
+
+func (XML) MediaTypes() []string {
+	return []string{echo.MIMEApplicationXML, echo.MIMETextXML}
+}
+
+func (XML) Marshal(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func (XML) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+type MsgPack struct{}
+
+func (MsgPack) ContentType() string {
+	return MIMEApplicationMsgPack
+}
+
+func (MsgPack) MediaTypes() []string {
+	return []string{MIMEApplicationMsgPack, "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (MsgPack) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgPack) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type CBOR struct{}
+
+func (CBOR) ContentType() string {
+	return MIMEApplicationCBOR
+}
+
+func (CBOR) MediaTypes() []string {
+	return []string{MIMEApplicationCBOR}
+}
+
+func (CBOR) Marshal(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (CBOR) Unmarshal(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, v)
+}
@@ -0,0 +1,189 @@
+package render
+
+import (
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const codecKey = "render.codec"
+
+type Codec interface {
+	ContentType() string
+	MediaTypes() []string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type Renderer struct {
+	codecs []Codec
+}
+
+var Default = NewRenderer(JSON{}, XML{}, MsgPack{}, CBOR{})
+
+// NewRenderer returns a Renderer whose first codec is used when the client has no preference.
+func NewRenderer(codecs ...Codec) *Renderer {
+	return &Renderer{codecs: codecs}
+}
+
+func (r *Renderer) Register(codec Codec) {
+	r.codecs = append(r.codecs, codec)
+}
+
+func (r *Renderer) Negotiate(accept string) (Codec, error) {
+	if strings.TrimSpace(accept) == "" {
+		return r.codecs[0], nil
+	}
+	for _, rng := range parseAccept(accept) {
+		for _, codec := range r.codecs {
+			for _, mediaType := range codec.MediaTypes() {
+				if rng.match(mediaType) {
+					return codec, nil
+				}
+			}
+		}
+	}
+	return nil, serverErr.ErrNotAcceptable
+}
+
+func (r *Renderer) Lookup(contentType string) (Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, serverErr.ErrUnsupportedMediaType
+	}
+	for _, codec := range r.codecs {
+		for _, t := range codec.MediaTypes() {
+			if t == mediaType {
+				return codec, nil
+			}
+		}
+	}
+	return nil, serverErr.ErrUnsupportedMediaType
+}
+
+// Render writes v with the codec chosen by Select, negotiating on the spot when Select did not run.
+// An unacceptable Accept header falls back to the default codec instead of failing.
+func (r *Renderer) Render(c echo.Context, code int, v interface{}) error {
+	codec, ok := c.Get(codecKey).(Codec)
+	if !ok {
+		var err error
+		codec, err = r.Negotiate(c.Request().Header.Get(echo.HeaderAccept))
+		if err != nil {
+			codec = r.codecs[0]
+		}
+	}
+	_, span := tracing.Tracer().Start(c.Request().Context(), "render.Marshal",
+		trace.WithAttributes(attribute.String("content_type", codec.ContentType())))
+	data, err := codec.Marshal(v)
+	span.SetAttributes(attribute.Int("bytes", len(data)))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	addVary(c.Response().Header(), echo.HeaderAccept)
+	return c.Blob(code, codec.ContentType(), data)
+}
+
+// addVary lists name in Vary unless a middleware already did.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values(echo.HeaderVary) {
+		for _, listed := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(listed), name) {
+				return
+			}
+		}
+	}
+	header.Add(echo.HeaderVary, name)
+}
+
+func (r *Renderer) Bind(c echo.Context, v interface{}) error {
+	req := c.Request()
+	if req.ContentLength == 0 {
+		return nil
+	}
+	codec, err := r.Lookup(req.Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	err = codec.Unmarshal(data, v)
+	if err != nil {
+		logger.FromContext(req.Context()).WithFields(logrus.Fields{
+			"Error":        err,
+			"Content-Type": codec.ContentType(),
+		}).Error("decode err")
+		err = serverErr.ErrBadParamInput
+	}
+	return err
+}
+
+// Select negotiates the codec for the request and remembers it for Render, so that a handler
+// can reject an unacceptable Accept header before it changes anything.
+func (r *Renderer) Select(c echo.Context) error {
+	codec, err := r.Negotiate(c.Request().Header.Get(echo.HeaderAccept))
+	if err != nil {
+		return err
+	}
+	c.Set(codecKey, codec)
+	return nil
+}
+
+func Respond(c echo.Context, code int, v interface{}) error {
+	return Default.Render(c, code, v)
+}
+
+func Bind(c echo.Context, v interface{}) error {
+	return Default.Bind(c, v)
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func (m mediaRange) match(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
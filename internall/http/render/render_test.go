@@ -0,0 +1,114 @@
+package render_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/render"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testPerson = &entity.Person{ID: 1, Email: "test@test.ru", Phone: "1234", FirstName: "test"}
+
+func TestRenderer_Negotiate(t *testing.T) {
+	tests := []struct {
+		name      string
+		accept    string
+		waitCodec render.Codec
+		waitErr   error
+	}{
+		{name: "empty", accept: "", waitCodec: render.JSON{}},
+		{name: "any", accept: "*/*", waitCodec: render.JSON{}},
+		{name: "json", accept: "application/json", waitCodec: render.JSON{}},
+		{name: "xml", accept: "application/xml", waitCodec: render.XML{}},
+		{name: "text xml", accept: "text/xml", waitCodec: render.XML{}},
+		{name: "msgpack alias", accept: "application/x-msgpack", waitCodec: render.MsgPack{}},
+		{name: "cbor", accept: "application/cbor", waitCodec: render.CBOR{}},
+		{name: "quality", accept: "application/json;q=0.5, application/cbor", waitCodec: render.CBOR{}},
+		{name: "type wildcard", accept: "text/html, application/*;q=0.1", waitCodec: render.JSON{}},
+		{name: "excluded", accept: "application/json;q=0, application/xml", waitCodec: render.XML{}},
+		{name: "not acceptable", accept: "text/html", waitErr: serverErr.ErrNotAcceptable},
+	}
+	for _, test := range tests {
+		codec, err := render.Default.Negotiate(test.accept)
+		assert.Equal(t, test.waitErr, err, test.name)
+		assert.Equal(t, test.waitCodec, codec, test.name)
+	}
+}
+
+func TestRenderer_RoundTrip(t *testing.T) {
+	codecs := []render.Codec{render.JSON{}, render.XML{}, render.MsgPack{}, render.CBOR{}}
+	for _, codec := range codecs {
+		data, err := codec.Marshal(testPerson)
+		require.NoError(t, err)
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/", strings.NewReader(string(data)))
+		req.Header.Set(echo.HeaderContentType, codec.MediaTypes()[0])
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		result := &entity.Person{}
+		err = render.Bind(c, result)
+		require.NoError(t, err, codec.ContentType())
+		result.XMLName = testPerson.XMLName
+		assert.Equal(t, testPerson, result, codec.ContentType())
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/", nil)
+	req.Header.Set(echo.HeaderAccept, "application/xml")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, render.Default.Select(c))
+	err := render.Respond(c, http.StatusOK, testPerson)
+	require.NoError(t, err)
+	assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, echo.HeaderAccept, rec.Header().Get(echo.HeaderVary))
+	assert.Contains(t, rec.Body.String(), "<person><id>1</id><email>test@test.ru</email>")
+}
+
+func TestRenderer_RenderVaryListed(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	// as set by the HTTP cache middleware
+	rec.Header().Add(echo.HeaderVary, "accept, Authorization")
+
+	require.NoError(t, render.Default.Select(c))
+	require.NoError(t, render.Respond(c, http.StatusOK, testPerson))
+	assert.Equal(t, []string{"accept, Authorization"}, rec.Header().Values(echo.HeaderVary))
+}
+
+func TestRenderer_Bind(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		waitErr     error
+	}{
+		{name: "empty body", contentType: "", body: "", waitErr: nil},
+		{name: "unsupported", contentType: "text/plain", body: "test", waitErr: serverErr.ErrUnsupportedMediaType},
+		{name: "missing content type", contentType: "", body: "{}", waitErr: serverErr.ErrUnsupportedMediaType},
+		{name: "malformed", contentType: echo.MIMEApplicationJSON, body: "{", waitErr: serverErr.ErrBadParamInput},
+	}
+	for _, test := range tests {
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/", strings.NewReader(test.body))
+		if test.contentType != "" {
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+		}
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		err := render.Bind(c, &entity.Person{})
+		assert.Equal(t, test.waitErr, err, test.name)
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/xml"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/http/render"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	Logic entity.WebhookLogic
+}
+
+type ResponseWebhooks struct {
+	XMLName xml.Name          `json:"-" xml:"response" msgpack:"-" cbor:"-"`
+	Data    []*entity.Webhook `json:"data" xml:"data>webhook" msgpack:"data" cbor:"data"`
+}
+
+type ResponseDeliveries struct {
+	XMLName xml.Name                  `json:"-" xml:"response" msgpack:"-" cbor:"-"`
+	Data    []*entity.WebhookDelivery `json:"data" xml:"data>delivery" msgpack:"data" cbor:"data"`
+}
+
+func (h *WebhookHandler) GetWebhooks(c echo.Context) error {
+	webhooks, err := h.Logic.GetAll(c.Request().Context())
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Info("Get webhooks Successful")
+	return render.Respond(c, http.StatusOK, &ResponseWebhooks{Data: webhooks})
+}
+
+func (h *WebhookHandler) GetWebhook(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	webhook, err := h.Logic.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Get webhook id = %v Successful", id)
+	return render.Respond(c, http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	req := &entity.Webhook{}
+	err := render.Bind(c, req)
+	if err != nil {
+		return getError(c, err)
+	}
+	webhook, err := h.Logic.Create(c.Request().Context(), req)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Create webhook id = %v Successful", webhook.ID)
+	return respondSecret(c, http.StatusCreated, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	req := &entity.Webhook{}
+	err := render.Bind(c, req)
+	if err != nil {
+		return getError(c, err)
+	}
+	webhook, err := h.Logic.Update(c.Request().Context(), id, req)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Update webhook id = %v Successful", id)
+	if webhook.Secret != "" {
+		return respondSecret(c, http.StatusOK, webhook)
+	}
+	return render.Respond(c, http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := h.Logic.Delete(c.Request().Context(), id)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Delete webhook id = %v Successful", id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	deliveries, err := h.Logic.GetDeliveries(c.Request().Context(), id)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Get deliveries of webhook id = %v Successful", id)
+	return render.Respond(c, http.StatusOK, &ResponseDeliveries{Data: deliveries})
+}
+
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	deliveryID, _ := strconv.Atoi(c.Param("delivery_id"))
+	delivery, err := h.Logic.Redeliver(c.Request().Context(), id, deliveryID)
+	if err != nil {
+		return getError(c, err)
+	}
+	logger.FromContext(c.Request().Context()).Infof("Redeliver delivery id = %v Successful", deliveryID)
+	return render.Respond(c, http.StatusAccepted, delivery)
+}
+
+func NewWebhookHandler(e *echo.Echo, logic entity.WebhookLogic) {
+	handler := &WebhookHandler{Logic: logic}
+	e.GET("/admin/webhooks", handler.GetWebhooks, negotiate)
+	e.POST("/admin/webhooks", handler.CreateWebhook, negotiate)
+	e.GET("/admin/webhooks/:id", handler.GetWebhook, negotiate)
+	e.PUT("/admin/webhooks/:id", handler.UpdateWebhook, negotiate)
+	e.DELETE("/admin/webhooks/:id", handler.DeleteWebhook, negotiate)
+	e.GET("/admin/webhooks/:id/deliveries", handler.GetDeliveries, negotiate)
+	e.POST("/admin/webhooks/:id/deliveries/:delivery_id/redeliver", handler.Redeliver, negotiate)
+}
@@ -0,0 +1,163 @@
+package http_test
+
+import (
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	personHandler "github.com/RomanUtolin/RESTful-CRUD/internall/http"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testWebhook        = &entity.Webhook{ID: 1, URL: "https://partner.test/hooks", Events: []entity.EventType{entity.PersonCreated}}
+	testCreatedWebhook = &entity.Webhook{ID: 1, URL: "https://partner.test/hooks", Events: []entity.EventType{entity.PersonCreated}, Secret: "whsec_0123"}
+	testWebhookReq     = &entity.Webhook{URL: "https://partner.test/hooks", Events: []entity.EventType{entity.PersonCreated}}
+	testWebhookReqJson = `{"url":"https://partner.test/hooks","events":["PersonCreated"]}`
+	testDelivery       = &entity.WebhookDelivery{ID: 5, WebhookID: 1, EventID: 7, EventType: entity.PersonCreated, Payload: json.RawMessage(`{"id":7}`), Status: entity.DeliveryDead, Attempts: 8, ResponseStatus: 500, LastError: "unexpected status 500"}
+)
+
+func TestWebhookHandler(t *testing.T) {
+	listJson, _ := json.Marshal(personHandler.ResponseWebhooks{Data: []*entity.Webhook{testWebhook}})
+	webhookJson, _ := json.Marshal(testWebhook)
+	createdJson, _ := json.Marshal(testCreatedWebhook)
+	deliveriesJson, _ := json.Marshal(personHandler.ResponseDeliveries{Data: []*entity.WebhookDelivery{testDelivery}})
+	redelivered := *testDelivery
+	redelivered.Status, redelivered.Attempts = entity.DeliveryPending, 0
+	redeliveredJson, _ := json.Marshal(&redelivered)
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		mockFunc     func(mockUCase *mocks.WebhookLogic)
+		waitCode     int
+		waitResponse string
+	}{
+		{
+			name:   "list",
+			method: echo.GET,
+			path:   "/admin/webhooks",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("GetAll", mock.Anything).Return([]*entity.Webhook{testWebhook}, nil)
+			},
+			waitCode:     http.StatusOK,
+			waitResponse: string(listJson),
+		},
+		{
+			name:   "get",
+			method: echo.GET,
+			path:   "/admin/webhooks/1",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("GetByID", mock.Anything, 1).Return(testWebhook, nil)
+			},
+			waitCode:     http.StatusOK,
+			waitResponse: string(webhookJson),
+		},
+		{
+			name:   "create",
+			method: echo.POST,
+			path:   "/admin/webhooks",
+			body:   testWebhookReqJson,
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("Create", mock.Anything, testWebhookReq).Return(testCreatedWebhook, nil)
+			},
+			waitCode:     http.StatusCreated,
+			waitResponse: string(createdJson),
+		},
+		{
+			name:   "create invalid",
+			method: echo.POST,
+			path:   "/admin/webhooks",
+			body:   `{"url":"ftp://partner.test"}`,
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("Create", mock.Anything, &entity.Webhook{URL: "ftp://partner.test"}).Return(nil, serverErr.ErrBadParamInput)
+			},
+			waitCode:     http.StatusBadRequest,
+			waitResponse: string(jsonErrBadParam),
+		},
+		{
+			name:   "update",
+			method: echo.PUT,
+			path:   "/admin/webhooks/1",
+			body:   testWebhookReqJson,
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("Update", mock.Anything, 1, testWebhookReq).Return(testWebhook, nil)
+			},
+			waitCode:     http.StatusOK,
+			waitResponse: string(webhookJson),
+		},
+		{
+			name:   "delete",
+			method: echo.DELETE,
+			path:   "/admin/webhooks/1",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("Delete", mock.Anything, 1).Return(nil)
+			},
+			waitCode: http.StatusNoContent,
+		},
+		{
+			name:   "deliveries",
+			method: echo.GET,
+			path:   "/admin/webhooks/1/deliveries",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("GetDeliveries", mock.Anything, 1).Return([]*entity.WebhookDelivery{testDelivery}, nil)
+			},
+			waitCode:     http.StatusOK,
+			waitResponse: string(deliveriesJson),
+		},
+		{
+			name:   "deliveries forbidden",
+			method: echo.GET,
+			path:   "/admin/webhooks/1/deliveries",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("GetDeliveries", mock.Anything, 1).Return(nil, serverErr.ErrForbidden)
+			},
+			waitCode:     http.StatusForbidden,
+			waitResponse: string(jsonErrForbidden),
+		},
+		{
+			name:   "redeliver",
+			method: echo.POST,
+			path:   "/admin/webhooks/1/deliveries/5/redeliver",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("Redeliver", mock.Anything, 1, 5).Return(&redelivered, nil)
+			},
+			waitCode:     http.StatusAccepted,
+			waitResponse: string(redeliveredJson),
+		},
+		{
+			name:   "redeliver not found",
+			method: echo.POST,
+			path:   "/admin/webhooks/2/deliveries/5/redeliver",
+			mockFunc: func(mockUCase *mocks.WebhookLogic) {
+				mockUCase.On("Redeliver", mock.Anything, 2, 5).Return(nil, serverErr.ErrNotFound)
+			},
+			waitCode:     http.StatusNotFound,
+			waitResponse: string(jsonErrNotFound),
+		},
+	}
+	for _, test := range tests {
+		mockUCase := new(mocks.WebhookLogic)
+		test.mockFunc(mockUCase)
+		e := echo.New()
+		personHandler.NewWebhookHandler(e, mockUCase)
+
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if test.body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.waitCode, rec.Code, test.name)
+		assert.Equal(t, test.waitResponse, strings.Trim(rec.Body.String(), "\n"), test.name)
+		mockUCase.AssertExpectations(t)
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey int
+
+const (
+	entryKey contextKey = iota
+	requestIDKey
+)
+
+// WithFields returns a copy of ctx whose logger carries fields in addition to the ones already set.
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return context.WithValue(ctx, entryKey, FromContext(ctx).WithFields(fields))
+}
+
+// FromContext returns the logger of ctx, the standard logger when ctx has none.
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry, ok := ctx.Value(entryKey).(*logrus.Entry)
+	if !ok {
+		return logrus.NewEntry(logrus.StandardLogger())
+	}
+	return entry
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, id)
+	return WithFields(ctx, logrus.Fields{"request_id": id})
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,32 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+
+	ctx := logger.WithRequestID(context.Background(), "abc")
+	ctx = logger.WithFields(ctx, logrus.Fields{"route": "/person"})
+	logger.FromContext(ctx).Info("hello")
+
+	line := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "abc", line["request_id"])
+	assert.Equal(t, "/person", line["route"])
+	assert.Equal(t, "abc", logger.RequestIDFromContext(ctx))
+	assert.Equal(t, "", logger.RequestIDFromContext(context.Background()))
+}
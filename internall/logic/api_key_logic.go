@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// lastUsedPrecision limits the writes made by authentication to one per key and interval.
+const lastUsedPrecision = time.Minute
+
+type APIKeyLogic struct {
+	Rep            entity.APIKeyRepository
+	TimeoutContext *reload.Value[time.Duration]
+}
+
+func NewAPIKeyLogic(rep entity.APIKeyRepository, timeoutContext *reload.Value[time.Duration]) entity.APIKeyLogic {
+	return &APIKeyLogic{rep, timeoutContext}
+}
+
+func (l *APIKeyLogic) GetAll(ctx context.Context) ([]*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	return l.Rep.GetAll(ctx)
+}
+
+func (l *APIKeyLogic) Issue(ctx context.Context, req *entity.APIKey) (*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	err := validator.New().Struct(req)
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"Error":  err,
+			"Name":   req.Name,
+			"Scopes": req.Scopes,
+		}).Error("validate err")
+		return nil, serverErr.ErrBadParamInput
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		logger.FromContext(ctx).WithField("Expires_at", req.ExpiresAt).Error("api key expires in the past")
+		return nil, serverErr.ErrBadParamInput
+	}
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	newKey := &entity.APIKey{
+		Name:      req.Name,
+		Prefix:    keyPrefix(key),
+		Hash:      hashKey(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	newKey, err = l.Rep.Create(ctx, newKey)
+	if err != nil {
+		return nil, err
+	}
+	newKey.Key = key
+	return newKey, nil
+}
+
+func (l *APIKeyLogic) Rotate(ctx context.Context, id int) (*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if id == 0 {
+		return nil, serverErr.ErrNotFound
+	}
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	err = l.Rep.Rotate(ctx, id, keyPrefix(key), hashKey(key))
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := l.Rep.GetByID(ctx, id)
+	if apiKey == nil && err == nil {
+		err = serverErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+func (l *APIKeyLogic) Revoke(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if id == 0 {
+		return serverErr.ErrNotFound
+	}
+	return l.Rep.Revoke(ctx, id)
+}
+
+// Authenticate returns the active key matching the plaintext key or ErrNotFound.
+func (l *APIKeyLogic) Authenticate(ctx context.Context, key string) (*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if !strings.HasPrefix(key, entity.APIKeyPrefix) {
+		return nil, serverErr.ErrNotFound
+	}
+	apiKey, err := l.Rep.GetByHash(ctx, hashKey(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey == nil || apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, serverErr.ErrNotFound
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastUsedPrecision {
+		err = l.Rep.TouchLastUsed(ctx, apiKey.ID)
+		if err != nil {
+			logger.FromContext(ctx).WithField("Error", err).Warning("touch api key last_used_at")
+		}
+	}
+	return apiKey, nil
+}
+
+func generateKey() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return entity.APIKeyPrefix + hex.EncodeToString(secret), nil
+}
+
+// keyPrefix is the part of the key kept in clear to tell keys apart.
+func keyPrefix(key string) string {
+	return key[:len(entity.APIKeyPrefix)+8]
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+package logic_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = entity.APIKeyPrefix + "0123456789abcdef"
+
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeyLogic_Issue(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name     string
+		req      *entity.APIKey
+		mockFunc func(mockRep *mocks.APIKeyRepository)
+		waitErr  error
+	}{
+		{
+			name: "valid",
+			req:  &entity.APIKey{Name: "batch", Scopes: []string{"person:read"}},
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {
+				mockRep.On("Create", mock.Anything, mock.AnythingOfType("*entity.APIKey")).
+					Return(func(ctx context.Context, req *entity.APIKey) *entity.APIKey {
+						req.ID = 1
+						return req
+					}, nil)
+			},
+		},
+		{
+			name:     "no scopes",
+			req:      &entity.APIKey{Name: "batch"},
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+		{
+			name:     "expired",
+			req:      &entity.APIKey{Name: "batch", Scopes: []string{"person:read"}, ExpiresAt: &past},
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+	}
+	for _, test := range tests {
+		mockRep := new(mocks.APIKeyRepository)
+		test.mockFunc(mockRep)
+		l := logic.NewAPIKeyLogic(mockRep, reload.NewValue(time.Second*2))
+
+		key, err := l.Issue(context.TODO(), test.req)
+		assert.Equal(t, test.waitErr, err, test.name)
+		if test.waitErr == nil {
+			require.NotNil(t, key, test.name)
+			assert.True(t, strings.HasPrefix(key.Key, entity.APIKeyPrefix), test.name)
+			assert.True(t, strings.HasPrefix(key.Key, key.Prefix), test.name)
+			assert.Equal(t, keyHash(key.Key), key.Hash, test.name)
+		}
+		mockRep.AssertExpectations(t)
+	}
+}
+
+func TestAPIKeyLogic_Rotate(t *testing.T) {
+	mockRep := new(mocks.APIKeyRepository)
+	var hash string
+	mockRep.On("Rotate", mock.Anything, 1, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { hash = args.String(3) }).
+		Return(nil)
+	mockRep.On("GetByID", mock.Anything, 1).Return(&entity.APIKey{ID: 1, Name: "batch"}, nil)
+	mockRep.On("Rotate", mock.Anything, 2, mock.Anything, mock.Anything).Return(serverErr.ErrNotFound)
+	l := logic.NewAPIKeyLogic(mockRep, reload.NewValue(time.Second*2))
+
+	key, err := l.Rotate(context.TODO(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, keyHash(key.Key), hash)
+
+	_, err = l.Rotate(context.TODO(), 2)
+	assert.Equal(t, serverErr.ErrNotFound, err)
+	mockRep.AssertExpectations(t)
+}
+
+func TestAPIKeyLogic_Authenticate(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	tests := []struct {
+		name     string
+		key      string
+		mockFunc func(mockRep *mocks.APIKeyRepository)
+		waitErr  error
+	}{
+		{
+			name: "valid, first use",
+			key:  testKey,
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {
+				mockRep.On("GetByHash", mock.Anything, keyHash(testKey)).Return(&entity.APIKey{ID: 1, ExpiresAt: &future}, nil)
+				mockRep.On("TouchLastUsed", mock.Anything, 1).Return(nil)
+			},
+		},
+		{
+			name: "valid, recently used",
+			key:  testKey,
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {
+				mockRep.On("GetByHash", mock.Anything, keyHash(testKey)).Return(&entity.APIKey{ID: 1, LastUsedAt: &now}, nil)
+			},
+		},
+		{
+			name:     "unknown prefix",
+			key:      "other_0123456789abcdef",
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {},
+			waitErr:  serverErr.ErrNotFound,
+		},
+		{
+			name: "unknown key",
+			key:  testKey,
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {
+				mockRep.On("GetByHash", mock.Anything, keyHash(testKey)).Return(nil, nil)
+			},
+			waitErr: serverErr.ErrNotFound,
+		},
+		{
+			name: "revoked",
+			key:  testKey,
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {
+				mockRep.On("GetByHash", mock.Anything, keyHash(testKey)).Return(&entity.APIKey{ID: 1, RevokedAt: &past}, nil)
+			},
+			waitErr: serverErr.ErrNotFound,
+		},
+		{
+			name: "expired",
+			key:  testKey,
+			mockFunc: func(mockRep *mocks.APIKeyRepository) {
+				mockRep.On("GetByHash", mock.Anything, keyHash(testKey)).Return(&entity.APIKey{ID: 1, ExpiresAt: &past}, nil)
+			},
+			waitErr: serverErr.ErrNotFound,
+		},
+	}
+	for _, test := range tests {
+		mockRep := new(mocks.APIKeyRepository)
+		test.mockFunc(mockRep)
+		l := logic.NewAPIKeyLogic(mockRep, reload.NewValue(time.Second*2))
+
+		_, err := l.Authenticate(context.TODO(), test.key)
+		assert.Equal(t, test.waitErr, err, test.name)
+		mockRep.AssertExpectations(t)
+	}
+}
@@ -0,0 +1,53 @@
+package logic
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+)
+
+// APIKeyPolicy restricts the key management to principals granted the admin operation.
+// Authenticate is not restricted as it runs before there is a principal.
+type APIKeyPolicy struct {
+	Logic entity.APIKeyLogic
+	Rules PolicyRules
+}
+
+func NewAPIKeyPolicy(logic entity.APIKeyLogic, rules PolicyRules) entity.APIKeyLogic {
+	return &APIKeyPolicy{logic, rules}
+}
+
+func (p *APIKeyPolicy) GetAll(ctx context.Context) ([]*entity.APIKey, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.GetAll(ctx)
+}
+
+func (p *APIKeyPolicy) Issue(ctx context.Context, req *entity.APIKey) (*entity.APIKey, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Issue(ctx, req)
+}
+
+func (p *APIKeyPolicy) Rotate(ctx context.Context, id int) (*entity.APIKey, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Rotate(ctx, id)
+}
+
+func (p *APIKeyPolicy) Revoke(ctx context.Context, id int) error {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return err
+	}
+	return p.Logic.Revoke(ctx, id)
+}
+
+func (p *APIKeyPolicy) Authenticate(ctx context.Context, key string) (*entity.APIKey, error) {
+	return p.Logic.Authenticate(ctx, key)
+}
@@ -0,0 +1,33 @@
+package logic_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestAPIKeyPolicy(t *testing.T) {
+	rules := logic.PolicyRules{Grants: map[logic.Operation][]string{logic.OperationAdmin: {"admin"}}}
+	admin := auth.WithPrincipal(context.TODO(), &auth.Principal{Subject: "1", Roles: []string{"admin"}})
+	user := auth.WithPrincipal(context.TODO(), &auth.Principal{Subject: "2", Scopes: []string{"person:admin"}})
+
+	mockLogic := new(mocks.APIKeyLogic)
+	mockLogic.On("GetAll", mock.Anything).Return([]*entity.APIKey{}, nil)
+	mockLogic.On("Authenticate", mock.Anything, testKey).Return(&entity.APIKey{ID: 1}, nil)
+	policy := logic.NewAPIKeyPolicy(mockLogic, rules)
+
+	_, err := policy.GetAll(admin)
+	assert.NoError(t, err)
+	_, err = policy.Issue(user, &entity.APIKey{})
+	assert.Equal(t, serverErr.ErrForbidden, err)
+	assert.Equal(t, serverErr.ErrForbidden, policy.Revoke(context.TODO(), 1))
+	_, err = policy.Authenticate(context.TODO(), testKey)
+	assert.NoError(t, err)
+	mockLogic.AssertExpectations(t)
+}
@@ -2,8 +2,11 @@ package logic
 
 import (
 	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
 	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"math"
@@ -12,19 +15,30 @@ import (
 
 type PersonLogic struct {
 	Rep            entity.PersonRepository
-	TimeoutContext time.Duration
+	TimeoutContext *reload.Value[time.Duration]
 }
 
-func NewPersonLogic(rep entity.PersonRepository, timeoutContext time.Duration) entity.PersonLogic {
+func NewPersonLogic(rep entity.PersonRepository, timeoutContext *reload.Value[time.Duration]) entity.PersonLogic {
 	return &PersonLogic{rep, timeoutContext}
 }
 
-func (p *PersonLogic) GetPersons(ctx context.Context, email, phone, firstName string, page, limit int) ([]*entity.Person, int, int, int, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext)
+func (p *PersonLogic) GetPersons(ctx context.Context, email, phone, firstName string, fields []string, page, limit int, count entity.CountMode) (*entity.PersonPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext.Load())
 	defer cancel()
 	var persons []*entity.Person
-	var count int
-	var err error
+	var total int
+	var countErr error
+	err := isFieldsValid(ctx, fields)
+	if err != nil {
+		return nil, err
+	}
+	if count == "" {
+		count = entity.CountExact
+	}
+	if count != entity.CountExact && count != entity.CountEstimated && count != entity.CountNone {
+		logger.FromContext(ctx).WithField("Count", count).Error("unknown count mode")
+		return nil, serverErr.ErrBadParamInput
+	}
 	if page == 0 {
 		page = 1
 	}
@@ -32,31 +46,75 @@ func (p *PersonLogic) GetPersons(ctx context.Context, email, phone, firstName st
 		limit = 10
 	}
 	offset := (page - 1) * limit
+	// without an exact count one extra row tells whether there is a next page
+	fetch := limit
+	if count != entity.CountExact {
+		fetch = limit + 1
+	}
 	switch {
 	case email != "":
-		persons, err = p.Rep.GetAllByEmail(ctx, email, limit, offset)
-		count, err = p.Rep.CountAllByEmail(ctx, email)
+		persons, err = p.Rep.GetAllByEmail(ctx, email, fields, fetch, offset)
+		total, countErr = countPersons(ctx, count,
+			func(ctx context.Context) (int, error) { return p.Rep.CountAllByEmail(ctx, email) },
+			func(ctx context.Context) (int, error) { return p.Rep.EstimateCountAllByEmail(ctx, email) })
 	case phone != "":
-		persons, err = p.Rep.GetAllByPhone(ctx, phone, limit, offset)
-		count, err = p.Rep.CountAllByPhone(ctx, phone)
+		persons, err = p.Rep.GetAllByPhone(ctx, phone, fields, fetch, offset)
+		total, countErr = countPersons(ctx, count,
+			func(ctx context.Context) (int, error) { return p.Rep.CountAllByPhone(ctx, phone) },
+			func(ctx context.Context) (int, error) { return p.Rep.EstimateCountAllByPhone(ctx, phone) })
 	case firstName != "":
-		persons, err = p.Rep.GetAllByName(ctx, firstName, limit, offset)
-		count, err = p.Rep.CountAllByName(ctx, firstName)
+		persons, err = p.Rep.GetAllByName(ctx, firstName, fields, fetch, offset)
+		total, countErr = countPersons(ctx, count,
+			func(ctx context.Context) (int, error) { return p.Rep.CountAllByName(ctx, firstName) },
+			func(ctx context.Context) (int, error) { return p.Rep.EstimateCountAllByName(ctx, firstName) })
 	default:
-		persons, err = p.Rep.GetAll(ctx, limit, offset)
-		count, err = p.Rep.CountAll(ctx)
+		persons, err = p.Rep.GetAll(ctx, fields, fetch, offset)
+		total, countErr = countPersons(ctx, count, p.Rep.CountAll, p.Rep.EstimateCountAll)
+	}
+	if err == nil {
+		err = countErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &entity.PersonPage{Page: page, Count: count}
+	if count == entity.CountExact {
+		result.HasMore = offset+len(persons) < total
+	} else {
+		result.HasMore = len(persons) > limit
+		if result.HasMore {
+			persons = persons[:limit]
+		}
 	}
-	lastPage := int(math.Ceil(float64(count) / float64(limit)))
-	return persons, count, page, lastPage, err
+	if count == entity.CountEstimated {
+		// an outdated estimate must not contradict the rows already seen
+		seen := offset + len(persons)
+		if result.HasMore {
+			seen++
+		}
+		if total < seen {
+			total = seen
+		}
+	}
+	result.Persons = persons
+	if count != entity.CountNone {
+		result.Total = total
+		result.LastPage = int(math.Ceil(float64(total) / float64(limit)))
+	}
+	return result, nil
 }
 
-func (p *PersonLogic) GetOnePerson(ctx context.Context, id int) (*entity.Person, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext)
+func (p *PersonLogic) GetOnePerson(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext.Load())
 	defer cancel()
 	if id == 0 {
 		return nil, serverErr.ErrNotFound
 	}
-	person, err := p.Rep.GetByID(ctx, id)
+	err := isFieldsValid(ctx, fields)
+	if err != nil {
+		return nil, err
+	}
+	person, err := p.Rep.GetByID(ctx, id, fields)
 	if person == nil && err == nil {
 		err = serverErr.ErrNotFound
 	}
@@ -64,9 +122,11 @@ func (p *PersonLogic) GetOnePerson(ctx context.Context, id int) (*entity.Person,
 }
 
 func (p *PersonLogic) Create(ctx context.Context, req *entity.Person) (*entity.Person, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext)
+	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext.Load())
 	defer cancel()
-	err := isRequestValid(req)
+	// the checks before the write must not read a lagging replica
+	ctx = consistency.WithPrimary(ctx)
+	err := isRequestValid(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -78,16 +138,17 @@ func (p *PersonLogic) Create(ctx context.Context, req *entity.Person) (*entity.P
 }
 
 func (p *PersonLogic) Update(ctx context.Context, id int, req *entity.Person) (*entity.Person, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext)
+	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext.Load())
 	defer cancel()
+	ctx = consistency.WithPrimary(ctx)
 	if id == 0 {
 		return nil, serverErr.ErrNotFound
 	}
-	err := isRequestValid(req)
+	err := isRequestValid(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	_, err = p.GetOnePerson(ctx, id)
+	_, err = p.GetOnePerson(ctx, id, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +160,7 @@ func (p *PersonLogic) Update(ctx context.Context, id int, req *entity.Person) (*
 }
 
 func (p *PersonLogic) Delete(ctx context.Context, id int) error {
-	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext)
+	ctx, cancel := context.WithTimeout(ctx, p.TimeoutContext.Load())
 	defer cancel()
 	if id == 0 {
 		return serverErr.ErrNotFound
@@ -107,6 +168,16 @@ func (p *PersonLogic) Delete(ctx context.Context, id int) error {
 	return p.Rep.Delete(ctx, id)
 }
 
+func countPersons(ctx context.Context, count entity.CountMode, exact, estimated func(ctx context.Context) (int, error)) (int, error) {
+	switch count {
+	case entity.CountExact:
+		return exact(ctx)
+	case entity.CountEstimated:
+		return estimated(ctx)
+	}
+	return 0, nil
+}
+
 func (p *PersonLogic) findPerson(ctx context.Context, email string) error {
 	person, err := p.Rep.GetByEmail(ctx, email)
 	if person != nil && err == nil {
@@ -115,11 +186,11 @@ func (p *PersonLogic) findPerson(ctx context.Context, email string) error {
 	return err
 }
 
-func isRequestValid(req *entity.Person) error {
+func isRequestValid(ctx context.Context, req *entity.Person) error {
 	validate := validator.New()
 	err := validate.Struct(req)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
+		logger.FromContext(ctx).WithFields(logrus.Fields{
 			"Error":      err,
 			"Email":      req.Email,
 			"Phone":      req.Phone,
@@ -129,3 +200,20 @@ func isRequestValid(req *entity.Person) error {
 	}
 	return err
 }
+
+func isFieldsValid(ctx context.Context, fields []string) error {
+	for _, field := range fields {
+		valid := false
+		for _, name := range entity.PersonFields {
+			if field == name {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			logger.FromContext(ctx).WithField("Field", field).Error("unknown field")
+			return serverErr.ErrBadParamInput
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
 	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
 	"github.com/RomanUtolin/RESTful-CRUD/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -37,7 +38,7 @@ func TestPersonLogic_GetPersons(t *testing.T) {
 		{
 			name: "GetAllValid",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetAll", mock.Anything, 10, 0).Return(ListPerson, nil)
+				mockUCase.On("GetAll", mock.Anything, []string(nil), 10, 0).Return(ListPerson, nil)
 				mockUCase.On("CountAll", mock.Anything).Return(1, nil)
 			},
 			waitErr:      nil,
@@ -52,7 +53,7 @@ func TestPersonLogic_GetPersons(t *testing.T) {
 		{
 			name: "GetAllByEmailValid",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetAllByEmail", mock.Anything, testPerson.Email, 10, 0).Return(ListPerson, nil)
+				mockUCase.On("GetAllByEmail", mock.Anything, testPerson.Email, []string(nil), 10, 0).Return(ListPerson, nil)
 				mockUCase.On("CountAllByEmail", mock.Anything, testPerson.Email).Return(1, nil)
 			},
 			waitErr:      nil,
@@ -67,7 +68,7 @@ func TestPersonLogic_GetPersons(t *testing.T) {
 		{
 			name: "GetAllByPhoneValid",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetAllByPhone", mock.Anything, testPerson.Phone, 10, 0).Return(ListPerson, nil)
+				mockUCase.On("GetAllByPhone", mock.Anything, testPerson.Phone, []string(nil), 10, 0).Return(ListPerson, nil)
 				mockUCase.On("CountAllByPhone", mock.Anything, testPerson.Phone).Return(1, nil)
 			},
 			waitErr:      nil,
@@ -82,7 +83,7 @@ func TestPersonLogic_GetPersons(t *testing.T) {
 		{
 			name: "GetAllByNameValid",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetAllByName", mock.Anything, testPerson.FirstName, 10, 0).Return(ListPerson, nil)
+				mockUCase.On("GetAllByName", mock.Anything, testPerson.FirstName, []string(nil), 10, 0).Return(ListPerson, nil)
 				mockUCase.On("CountAllByName", mock.Anything, testPerson.FirstName).Return(1, nil)
 			},
 			waitErr:      nil,
@@ -97,7 +98,7 @@ func TestPersonLogic_GetPersons(t *testing.T) {
 		{
 			name: "store error",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetAll", mock.Anything, 10, 0).Return(nil, serverErr.ErrInternalServer)
+				mockUCase.On("GetAll", mock.Anything, []string(nil), 10, 0).Return(nil, serverErr.ErrInternalServer)
 				mockUCase.On("CountAll", mock.Anything).Return(0, serverErr.ErrInternalServer)
 			},
 			waitErr:      serverErr.ErrInternalServer,
@@ -113,19 +114,111 @@ func TestPersonLogic_GetPersons(t *testing.T) {
 	for _, test := range tests {
 		mockUCase := new(mocks.PersonRepository)
 		test.mockFunc(mockUCase)
-		personLogic := logic.NewPersonLogic(mockUCase, time.Second*2)
-		persons, count, page, lastPage, err := personLogic.GetPersons(context.TODO(), test.email, test.phone, test.firstName, 0, 0)
+		personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
+		result, err := personLogic.GetPersons(context.TODO(), test.email, test.phone, test.firstName, nil, 0, 0, "")
 
 		assert.Equal(t, test.waitErr, err)
-		assert.Equal(t, test.waitResult, persons)
-		assert.Equal(t, test.waitCount, count)
-		assert.Equal(t, test.waitPage, page)
-		assert.Equal(t, test.waitLastPage, lastPage)
+		if test.waitErr != nil {
+			assert.Nil(t, result)
+		} else {
+			assert.Equal(t, test.waitResult, result.Persons)
+			assert.Equal(t, test.waitCount, result.Total)
+			assert.Equal(t, test.waitPage, result.Page)
+			assert.Equal(t, test.waitLastPage, result.LastPage)
+			assert.Equal(t, entity.CountExact, result.Count)
+			assert.False(t, result.HasMore)
+		}
 
 		mockUCase.AssertExpectations(t)
 	}
 }
 
+func TestPersonLogic_GetPersonsFields(t *testing.T) {
+	ListPerson := []*entity.Person{{ID: testPerson.ID, FirstName: testPerson.FirstName}}
+	mockUCase := new(mocks.PersonRepository)
+	mockUCase.On("GetAll", mock.Anything, []string{"first_name"}, 10, 0).Return(ListPerson, nil)
+	mockUCase.On("CountAll", mock.Anything).Return(1, nil)
+	personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
+
+	result, err := personLogic.GetPersons(context.TODO(), "", "", "", []string{"first_name"}, 0, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, ListPerson, result.Persons)
+
+	_, err = personLogic.GetPersons(context.TODO(), "", "", "", []string{"password"}, 0, 0, "")
+	assert.Equal(t, serverErr.ErrBadParamInput, err)
+	_, err = personLogic.GetOnePerson(context.TODO(), testPerson.ID, []string{"password"})
+	assert.Equal(t, serverErr.ErrBadParamInput, err)
+	mockUCase.AssertExpectations(t)
+}
+
+func TestPersonLogic_GetPersonsCount(t *testing.T) {
+	ListPerson := []*entity.Person{testPerson, testPerson, testPerson}
+	tests := []struct {
+		name     string
+		mockFunc func(mockUCase *mocks.PersonRepository)
+		count    entity.CountMode
+		page     int
+		waitErr  error
+		waitPage *entity.PersonPage
+	}{
+		{
+			name: "exact",
+			mockFunc: func(mockUCase *mocks.PersonRepository) {
+				mockUCase.On("GetAllByEmail", mock.Anything, testPerson.Email, []string(nil), 2, 0).Return(ListPerson[:2], nil)
+				mockUCase.On("CountAllByEmail", mock.Anything, testPerson.Email).Return(3, nil)
+			},
+			count:    entity.CountExact,
+			page:     1,
+			waitPage: &entity.PersonPage{Persons: ListPerson[:2], Total: 3, Page: 1, LastPage: 2, HasMore: true, Count: entity.CountExact},
+		},
+		{
+			name: "estimated",
+			mockFunc: func(mockUCase *mocks.PersonRepository) {
+				mockUCase.On("GetAllByEmail", mock.Anything, testPerson.Email, []string(nil), 3, 0).Return(ListPerson[:2], nil)
+				mockUCase.On("EstimateCountAllByEmail", mock.Anything, testPerson.Email).Return(10, nil)
+			},
+			count:    entity.CountEstimated,
+			page:     1,
+			waitPage: &entity.PersonPage{Persons: ListPerson[:2], Total: 10, Page: 1, LastPage: 5, Count: entity.CountEstimated},
+		},
+		{
+			name: "estimated below rows seen",
+			mockFunc: func(mockUCase *mocks.PersonRepository) {
+				mockUCase.On("GetAllByEmail", mock.Anything, testPerson.Email, []string(nil), 3, 2).Return(ListPerson, nil)
+				mockUCase.On("EstimateCountAllByEmail", mock.Anything, testPerson.Email).Return(1, nil)
+			},
+			count:    entity.CountEstimated,
+			page:     2,
+			waitPage: &entity.PersonPage{Persons: ListPerson[:2], Total: 5, Page: 2, LastPage: 3, HasMore: true, Count: entity.CountEstimated},
+		},
+		{
+			name: "none",
+			mockFunc: func(mockUCase *mocks.PersonRepository) {
+				mockUCase.On("GetAllByEmail", mock.Anything, testPerson.Email, []string(nil), 3, 0).Return(ListPerson, nil)
+			},
+			count:    entity.CountNone,
+			page:     1,
+			waitPage: &entity.PersonPage{Persons: ListPerson[:2], Page: 1, HasMore: true, Count: entity.CountNone},
+		},
+		{
+			name:     "unknown",
+			mockFunc: func(mockUCase *mocks.PersonRepository) {},
+			count:    entity.CountMode("approximate"),
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+	}
+	for _, test := range tests {
+		mockUCase := new(mocks.PersonRepository)
+		test.mockFunc(mockUCase)
+		personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
+		result, err := personLogic.GetPersons(context.TODO(), testPerson.Email, "", "", nil, test.page, 2, test.count)
+
+		assert.Equal(t, test.waitErr, err, test.name)
+		assert.Equal(t, test.waitPage, result, test.name)
+		mockUCase.AssertExpectations(t)
+	}
+}
+
 func TestPersonLogic_GetOnePerson(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -136,7 +229,7 @@ func TestPersonLogic_GetOnePerson(t *testing.T) {
 		{
 			name: "valid",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(testPerson, nil)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
 			},
 			waitErr:    nil,
 			waitResult: testPerson,
@@ -144,7 +237,7 @@ func TestPersonLogic_GetOnePerson(t *testing.T) {
 		{
 			name: "store error",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(nil, serverErr.ErrInternalServer)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(nil, serverErr.ErrInternalServer)
 			},
 			waitErr:    serverErr.ErrInternalServer,
 			waitResult: nil,
@@ -152,7 +245,7 @@ func TestPersonLogic_GetOnePerson(t *testing.T) {
 		{
 			name: "not found",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(nil, serverErr.ErrNotFound)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(nil, serverErr.ErrNotFound)
 			},
 			waitErr:    serverErr.ErrNotFound,
 			waitResult: nil,
@@ -161,8 +254,8 @@ func TestPersonLogic_GetOnePerson(t *testing.T) {
 	for _, test := range tests {
 		mockUCase := new(mocks.PersonRepository)
 		test.mockFunc(mockUCase)
-		personLogic := logic.NewPersonLogic(mockUCase, time.Second*2)
-		person, err := personLogic.GetOnePerson(context.TODO(), testPerson.ID)
+		personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
+		person, err := personLogic.GetOnePerson(context.TODO(), testPerson.ID, nil)
 		assert.Equal(t, test.waitErr, err)
 		assert.Equal(t, test.waitResult, person)
 
@@ -207,7 +300,7 @@ func TestPersonLogic_Create(t *testing.T) {
 	for _, test := range tests {
 		mockUCase := new(mocks.PersonRepository)
 		test.mockFunc(mockUCase)
-		personLogic := logic.NewPersonLogic(mockUCase, time.Second*2)
+		personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
 		person, err := personLogic.Create(context.TODO(), testPerson)
 		assert.Equal(t, test.waitErr, err)
 		assert.Equal(t, test.waitResult, person)
@@ -226,7 +319,7 @@ func TestPersonLogic_Update(t *testing.T) {
 		{
 			name: "valid",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(testPerson, nil)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
 				mockUCase.On("GetByEmail", mock.Anything, testPerson.Email).Return(nil, nil)
 				mockUCase.On("Update", mock.Anything, testPerson.ID, testPerson).Return(testPerson, nil)
 			},
@@ -236,7 +329,7 @@ func TestPersonLogic_Update(t *testing.T) {
 		{
 			name: "invalid id",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(nil, serverErr.ErrNotFound)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(nil, serverErr.ErrNotFound)
 			},
 			waitErr:    serverErr.ErrNotFound,
 			waitResult: nil,
@@ -244,7 +337,7 @@ func TestPersonLogic_Update(t *testing.T) {
 		{
 			name: "store error",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(testPerson, nil)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
 				mockUCase.On("GetByEmail", mock.Anything, testPerson.Email).Return(nil, nil)
 				mockUCase.On("Update", mock.Anything, testPerson.ID, testPerson).Return(nil, serverErr.ErrInternalServer)
 			},
@@ -254,7 +347,7 @@ func TestPersonLogic_Update(t *testing.T) {
 		{
 			name: "Conflict Data in db",
 			mockFunc: func(mockUCase *mocks.PersonRepository) {
-				mockUCase.On("GetByID", mock.Anything, testPerson.ID).Return(testPerson, nil)
+				mockUCase.On("GetByID", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
 				mockUCase.On("GetByEmail", mock.Anything, testPerson.Email).Return(testPerson, nil)
 			},
 			waitErr:    serverErr.ErrConflict,
@@ -264,7 +357,7 @@ func TestPersonLogic_Update(t *testing.T) {
 	for _, test := range tests {
 		mockUCase := new(mocks.PersonRepository)
 		test.mockFunc(mockUCase)
-		personLogic := logic.NewPersonLogic(mockUCase, time.Second*2)
+		personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
 		person, err := personLogic.Update(context.TODO(), testPerson.ID, testPerson)
 		assert.Equal(t, test.waitErr, err)
 		assert.Equal(t, test.waitResult, person)
@@ -304,7 +397,7 @@ func TestPersonLogic_Delete(t *testing.T) {
 	for _, test := range tests {
 		mockUCase := new(mocks.PersonRepository)
 		test.mockFunc(mockUCase)
-		personLogic := logic.NewPersonLogic(mockUCase, time.Second*2)
+		personLogic := logic.NewPersonLogic(mockUCase, reload.NewValue(time.Second*2))
 		err := personLogic.Delete(context.TODO(), testPerson.ID)
 		assert.Equal(t, test.waitErr, err)
 
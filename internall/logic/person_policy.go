@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type Operation string
+
+const (
+	OperationRead   Operation = "read"
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+	OperationAdmin  Operation = "admin"
+)
+
+// PolicyRules map an operation to the scopes or roles allowed to perform it.
+// Grants allow it on every person, OwnerGrants only on the person of the principal, see auth.Principal.PersonID.
+type PolicyRules struct {
+	Grants      map[Operation][]string
+	OwnerGrants map[Operation][]string
+}
+
+type PersonPolicy struct {
+	Logic entity.PersonLogic
+	Rules PolicyRules
+}
+
+func NewPersonPolicy(logic entity.PersonLogic, rules PolicyRules) entity.PersonLogic {
+	return &PersonPolicy{logic, rules}
+}
+
+func (p *PersonPolicy) GetPersons(ctx context.Context, email, phone, firstName string, fields []string, page, limit int, count entity.CountMode) (*entity.PersonPage, error) {
+	err := p.authorize(ctx, OperationRead, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.GetPersons(ctx, email, phone, firstName, fields, page, limit, count)
+}
+
+func (p *PersonPolicy) GetOnePerson(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	err := p.authorize(ctx, OperationRead, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.GetOnePerson(ctx, id, fields)
+}
+
+func (p *PersonPolicy) Create(ctx context.Context, req *entity.Person) (*entity.Person, error) {
+	err := p.authorize(ctx, OperationCreate, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Create(ctx, req)
+}
+
+func (p *PersonPolicy) Update(ctx context.Context, id int, req *entity.Person) (*entity.Person, error) {
+	err := p.authorize(ctx, OperationUpdate, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Update(ctx, id, req)
+}
+
+func (p *PersonPolicy) Delete(ctx context.Context, id int) error {
+	err := p.authorize(ctx, OperationDelete, id)
+	if err != nil {
+		return err
+	}
+	return p.Logic.Delete(ctx, id)
+}
+
+// authorize checks the principal of ctx against the rules of operation on the person id, 0 meaning no single person.
+func (p *PersonPolicy) authorize(ctx context.Context, operation Operation, id int) error {
+	return authorize(ctx, p.Rules, operation, id)
+}
+
+func authorize(ctx context.Context, rules PolicyRules, operation Operation, id int) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if ok {
+		if isGranted(principal, rules.Grants[operation]) {
+			return nil
+		}
+		if id != 0 && principal.PersonID == id && isGranted(principal, rules.OwnerGrants[operation]) {
+			return nil
+		}
+	}
+	fields := logrus.Fields{
+		"audit":     true,
+		"operation": operation,
+		"person_id": id,
+	}
+	if ok {
+		fields["subject"] = principal.Subject
+		fields["auth_method"] = principal.Method
+	}
+	logger.FromContext(ctx).WithFields(fields).Warning("access denied")
+	return serverErr.ErrForbidden
+}
+
+func isGranted(principal *auth.Principal, grants []string) bool {
+	for _, grant := range grants {
+		if principal.HasScope(grant) || principal.HasRole(grant) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package logic_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strconv"
+	"testing"
+)
+
+var testRules = logic.PolicyRules{
+	Grants: map[logic.Operation][]string{
+		logic.OperationRead:   {"person:read", "person:admin"},
+		logic.OperationCreate: {"person:write", "person:admin"},
+		logic.OperationUpdate: {"person:admin"},
+		logic.OperationDelete: {"admin"},
+	},
+	OwnerGrants: map[logic.Operation][]string{
+		logic.OperationUpdate: {"person:write"},
+	},
+}
+
+func TestPersonPolicy(t *testing.T) {
+	reader := &auth.Principal{Subject: "7", Scopes: []string{"person:read"}}
+	writer := &auth.Principal{Subject: "auth0|5f1c", Scopes: []string{"person:write"}, PersonID: testPerson.ID}
+	// a subject that happens to be the id of a person does not own it
+	numeric := &auth.Principal{Subject: strconv.Itoa(testPerson.ID), Scopes: []string{"person:write"}}
+	admin := &auth.Principal{Subject: "admin", Roles: []string{"admin"}, Scopes: []string{"person:admin"}}
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		mockFunc  func(mockLogic *mocks.PersonLogic)
+		call      func(policy entity.PersonLogic, ctx context.Context) error
+		waitErr   error
+	}{
+		{
+			name:      "read granted by scope",
+			principal: reader,
+			mockFunc: func(mockLogic *mocks.PersonLogic) {
+				mockLogic.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
+			},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				_, err := policy.GetOnePerson(ctx, testPerson.ID, nil)
+				return err
+			},
+		},
+		{
+			name:      "create denied",
+			principal: reader,
+			mockFunc:  func(mockLogic *mocks.PersonLogic) {},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				_, err := policy.Create(ctx, testPerson)
+				return err
+			},
+			waitErr: serverErr.ErrForbidden,
+		},
+		{
+			name:      "update own person",
+			principal: writer,
+			mockFunc: func(mockLogic *mocks.PersonLogic) {
+				mockLogic.On("Update", mock.Anything, testPerson.ID, testPerson).Return(testPerson, nil)
+			},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				_, err := policy.Update(ctx, testPerson.ID, testPerson)
+				return err
+			},
+		},
+		{
+			name:      "update other person",
+			principal: writer,
+			mockFunc:  func(mockLogic *mocks.PersonLogic) {},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				_, err := policy.Update(ctx, 2, testPerson)
+				return err
+			},
+			waitErr: serverErr.ErrForbidden,
+		},
+		{
+			name:      "numeric subject owns nothing",
+			principal: numeric,
+			mockFunc:  func(mockLogic *mocks.PersonLogic) {},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				_, err := policy.Update(ctx, testPerson.ID, testPerson)
+				return err
+			},
+			waitErr: serverErr.ErrForbidden,
+		},
+		{
+			name:      "list is not owned",
+			principal: writer,
+			mockFunc:  func(mockLogic *mocks.PersonLogic) {},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				_, err := policy.GetPersons(ctx, "", "", "", nil, 0, 0, "")
+				return err
+			},
+			waitErr: serverErr.ErrForbidden,
+		},
+		{
+			name:      "delete granted by role",
+			principal: admin,
+			mockFunc: func(mockLogic *mocks.PersonLogic) {
+				mockLogic.On("Delete", mock.Anything, testPerson.ID).Return(nil)
+			},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				return policy.Delete(ctx, testPerson.ID)
+			},
+		},
+		{
+			name:      "no principal",
+			principal: nil,
+			mockFunc:  func(mockLogic *mocks.PersonLogic) {},
+			call: func(policy entity.PersonLogic, ctx context.Context) error {
+				return policy.Delete(ctx, testPerson.ID)
+			},
+			waitErr: serverErr.ErrForbidden,
+		},
+	}
+	for _, test := range tests {
+		mockLogic := new(mocks.PersonLogic)
+		test.mockFunc(mockLogic)
+		policy := logic.NewPersonPolicy(mockLogic, testRules)
+		ctx := context.TODO()
+		if test.principal != nil {
+			ctx = auth.WithPrincipal(ctx, test.principal)
+		}
+
+		err := test.call(policy, ctx)
+		assert.Equal(t, test.waitErr, err, test.name)
+		mockLogic.AssertExpectations(t)
+	}
+}
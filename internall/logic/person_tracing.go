@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedPersonLogic wraps every call of Logic in a span.
+type TracedPersonLogic struct {
+	Logic entity.PersonLogic
+}
+
+func NewTracedPersonLogic(logic entity.PersonLogic) entity.PersonLogic {
+	return &TracedPersonLogic{logic}
+}
+
+func (t *TracedPersonLogic) GetPersons(ctx context.Context, email, phone, firstName string, fields []string, page, limit int, count entity.CountMode) (*entity.PersonPage, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PersonLogic.GetPersons")
+	span.SetAttributes(
+		attribute.Int("page", page),
+		attribute.Int("limit", limit),
+		attribute.String("count", string(count)),
+		attribute.StringSlice("fields", fields),
+	)
+	result, err := t.Logic.GetPersons(ctx, email, phone, firstName, fields, page, limit, count)
+	if err == nil {
+		span.SetAttributes(attribute.Int("persons", len(result.Persons)))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+func (t *TracedPersonLogic) GetOnePerson(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PersonLogic.GetOnePerson")
+	span.SetAttributes(attribute.Int("person_id", id), attribute.StringSlice("fields", fields))
+	person, err := t.Logic.GetOnePerson(ctx, id, fields)
+	tracing.End(span, err)
+	return person, err
+}
+
+func (t *TracedPersonLogic) Create(ctx context.Context, req *entity.Person) (*entity.Person, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PersonLogic.Create")
+	person, err := t.Logic.Create(ctx, req)
+	if err == nil {
+		span.SetAttributes(attribute.Int("person_id", person.ID))
+	}
+	tracing.End(span, err)
+	return person, err
+}
+
+func (t *TracedPersonLogic) Update(ctx context.Context, id int, req *entity.Person) (*entity.Person, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PersonLogic.Update")
+	span.SetAttributes(attribute.Int("person_id", id))
+	person, err := t.Logic.Update(ctx, id, req)
+	tracing.End(span, err)
+	return person, err
+}
+
+func (t *TracedPersonLogic) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Tracer().Start(ctx, "PersonLogic.Delete")
+	span.SetAttributes(attribute.Int("person_id", id))
+	err := t.Logic.Delete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
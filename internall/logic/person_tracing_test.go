@@ -0,0 +1,40 @@
+package logic_test
+
+import (
+	"context"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestTracedPersonLogic(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	mockLogic := new(mocks.PersonLogic)
+	mockLogic.On("GetOnePerson", mock.Anything, testPerson.ID, []string(nil)).Return(testPerson, nil)
+	mockLogic.On("Delete", mock.Anything, 2).Return(serverErr.ErrNotFound)
+	traced := logic.NewTracedPersonLogic(mockLogic)
+
+	person, err := traced.GetOnePerson(context.TODO(), testPerson.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testPerson, person)
+	assert.Equal(t, serverErr.ErrNotFound, traced.Delete(context.TODO(), 2))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "PersonLogic.GetOnePerson", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.Int("person_id", testPerson.ID))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, "PersonLogic.Delete", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	mockLogic.AssertExpectations(t)
+}
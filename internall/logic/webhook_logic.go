@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/webhook"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"time"
+)
+
+// deliveriesLimit is the number of latest deliveries listed per webhook.
+const deliveriesLimit = 100
+
+type WebhookLogic struct {
+	Rep            entity.WebhookRepository
+	TimeoutContext *reload.Value[time.Duration]
+}
+
+func NewWebhookLogic(rep entity.WebhookRepository, timeoutContext *reload.Value[time.Duration]) entity.WebhookLogic {
+	return &WebhookLogic{rep, timeoutContext}
+}
+
+func (l *WebhookLogic) GetAll(ctx context.Context) ([]*entity.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	return l.Rep.GetAll(ctx)
+}
+
+func (l *WebhookLogic) GetByID(ctx context.Context, id int) (*entity.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if id == 0 {
+		return nil, serverErr.ErrNotFound
+	}
+	webhook, err := l.Rep.GetByID(ctx, id)
+	if webhook == nil && err == nil {
+		err = serverErr.ErrNotFound
+	}
+	return webhook, err
+}
+
+// Create generates the secret unless the request brings its own.
+func (l *WebhookLogic) Create(ctx context.Context, req *entity.Webhook) (*entity.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	err := isWebhookValid(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+	webhook := &entity.Webhook{URL: req.URL, Events: req.Events, Secret: secret}
+	return l.Rep.Create(ctx, webhook)
+}
+
+// Update keeps the secret when the request has none.
+func (l *WebhookLogic) Update(ctx context.Context, id int, req *entity.Webhook) (*entity.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if id == 0 {
+		return nil, serverErr.ErrNotFound
+	}
+	err := isWebhookValid(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &entity.Webhook{URL: req.URL, Events: req.Events, Secret: req.Secret}
+	return l.Rep.Update(ctx, id, webhook)
+}
+
+func (l *WebhookLogic) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if id == 0 {
+		return serverErr.ErrNotFound
+	}
+	return l.Rep.Delete(ctx, id)
+}
+
+// GetDeliveries lists the latest deliveries of the webhook, newest first.
+func (l *WebhookLogic) GetDeliveries(ctx context.Context, webhookID int) ([]*entity.WebhookDelivery, error) {
+	_, err := l.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	return l.Rep.GetDeliveries(ctx, webhookID, deliveriesLimit)
+}
+
+func (l *WebhookLogic) Redeliver(ctx context.Context, webhookID, id int) (*entity.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.TimeoutContext.Load())
+	defer cancel()
+	if webhookID == 0 || id == 0 {
+		return nil, serverErr.ErrNotFound
+	}
+	return l.Rep.Redeliver(ctx, webhookID, id)
+}
+
+func isWebhookValid(ctx context.Context, req *entity.Webhook) error {
+	err := validator.New().Struct(req)
+	if err == nil {
+		u, _ := url.Parse(req.URL)
+		if u.Scheme != "http" && u.Scheme != "https" {
+			err = serverErr.ErrBadParamInput
+		} else {
+			err = webhook.CheckHost(ctx, u.Hostname())
+		}
+	}
+	if err != nil {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"Error":  err,
+			"URL":    req.URL,
+			"Events": req.Events,
+		}).Error("validate err")
+		return serverErr.ErrBadParamInput
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return entity.WebhookSecretPrefix + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,117 @@
+package logic_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookLogic_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *entity.Webhook
+		mockFunc   func(mockRep *mocks.WebhookRepository)
+		waitSecret string
+		waitErr    error
+	}{
+		{
+			name: "generated secret",
+			req:  &entity.Webhook{URL: "https://partner.test/hooks", Events: []entity.EventType{entity.PersonCreated}},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {
+				mockRep.On("Create", mock.Anything, mock.AnythingOfType("*entity.Webhook")).
+					Return(func(ctx context.Context, req *entity.Webhook) *entity.Webhook {
+						req.ID = 1
+						return req
+					}, nil)
+			},
+		},
+		{
+			name: "own secret",
+			req:  &entity.Webhook{URL: "http://partner.test/hooks", Secret: "shared"},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {
+				mockRep.On("Create", mock.Anything, &entity.Webhook{URL: "http://partner.test/hooks", Secret: "shared"}).
+					Return(&entity.Webhook{ID: 1, URL: "http://partner.test/hooks", Secret: "shared"}, nil)
+			},
+			waitSecret: "shared",
+		},
+		{
+			name:     "no url",
+			req:      &entity.Webhook{},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+		{
+			name:     "not http",
+			req:      &entity.Webhook{URL: "ftp://partner.test/hooks"},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+		{
+			name:     "loopback",
+			req:      &entity.Webhook{URL: "http://localhost:8080/hooks"},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+		{
+			name:     "metadata service",
+			req:      &entity.Webhook{URL: "http://169.254.169.254/latest/meta-data"},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+		{
+			name:     "private network",
+			req:      &entity.Webhook{URL: "https://[fd00::1]/hooks"},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+		{
+			name:     "unknown event",
+			req:      &entity.Webhook{URL: "https://partner.test/hooks", Events: []entity.EventType{"PersonRenamed"}},
+			mockFunc: func(mockRep *mocks.WebhookRepository) {},
+			waitErr:  serverErr.ErrBadParamInput,
+		},
+	}
+	for _, test := range tests {
+		mockRep := new(mocks.WebhookRepository)
+		test.mockFunc(mockRep)
+		l := logic.NewWebhookLogic(mockRep, reload.NewValue(time.Second*2))
+
+		webhook, err := l.Create(context.TODO(), test.req)
+		assert.Equal(t, test.waitErr, err, test.name)
+		if test.waitErr == nil {
+			require.NotNil(t, webhook, test.name)
+			if test.waitSecret != "" {
+				assert.Equal(t, test.waitSecret, webhook.Secret, test.name)
+			} else {
+				assert.True(t, strings.HasPrefix(webhook.Secret, entity.WebhookSecretPrefix), test.name)
+			}
+		}
+		mockRep.AssertExpectations(t)
+	}
+}
+
+func TestWebhookLogic_GetDeliveries(t *testing.T) {
+	mockRep := new(mocks.WebhookRepository)
+	mockRep.On("GetByID", mock.Anything, 1).Return(&entity.Webhook{ID: 1}, nil)
+	mockRep.On("GetByID", mock.Anything, 2).Return(nil, nil)
+	mockRep.On("GetDeliveries", mock.Anything, 1, 100).Return([]*entity.WebhookDelivery{{ID: 5, WebhookID: 1}}, nil)
+	l := logic.NewWebhookLogic(mockRep, reload.NewValue(time.Second*2))
+
+	deliveries, err := l.GetDeliveries(context.TODO(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	_, err = l.GetDeliveries(context.TODO(), 2)
+	assert.Equal(t, serverErr.ErrNotFound, err)
+	_, err = l.Redeliver(context.TODO(), 1, 0)
+	assert.Equal(t, serverErr.ErrNotFound, err)
+	mockRep.AssertExpectations(t)
+}
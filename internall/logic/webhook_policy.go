@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+)
+
+// WebhookPolicy restricts the webhook management to principals granted the admin operation.
+type WebhookPolicy struct {
+	Logic entity.WebhookLogic
+	Rules PolicyRules
+}
+
+func NewWebhookPolicy(logic entity.WebhookLogic, rules PolicyRules) entity.WebhookLogic {
+	return &WebhookPolicy{logic, rules}
+}
+
+func (p *WebhookPolicy) GetAll(ctx context.Context) ([]*entity.Webhook, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.GetAll(ctx)
+}
+
+func (p *WebhookPolicy) GetByID(ctx context.Context, id int) (*entity.Webhook, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.GetByID(ctx, id)
+}
+
+func (p *WebhookPolicy) Create(ctx context.Context, req *entity.Webhook) (*entity.Webhook, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Create(ctx, req)
+}
+
+func (p *WebhookPolicy) Update(ctx context.Context, id int, req *entity.Webhook) (*entity.Webhook, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Update(ctx, id, req)
+}
+
+func (p *WebhookPolicy) Delete(ctx context.Context, id int) error {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return err
+	}
+	return p.Logic.Delete(ctx, id)
+}
+
+func (p *WebhookPolicy) GetDeliveries(ctx context.Context, webhookID int) ([]*entity.WebhookDelivery, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.GetDeliveries(ctx, webhookID)
+}
+
+func (p *WebhookPolicy) Redeliver(ctx context.Context, webhookID, id int) (*entity.WebhookDelivery, error) {
+	err := authorize(ctx, p.Rules, OperationAdmin, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.Logic.Redeliver(ctx, webhookID, id)
+}
@@ -0,0 +1,35 @@
+package logic_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logic"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestWebhookPolicy(t *testing.T) {
+	rules := logic.PolicyRules{Grants: map[logic.Operation][]string{logic.OperationAdmin: {"admin"}}}
+	admin := auth.WithPrincipal(context.TODO(), &auth.Principal{Subject: "1", Roles: []string{"admin"}})
+	user := auth.WithPrincipal(context.TODO(), &auth.Principal{Subject: "2", Scopes: []string{"person:write"}})
+
+	mockLogic := new(mocks.WebhookLogic)
+	mockLogic.On("GetAll", mock.Anything).Return([]*entity.Webhook{}, nil)
+	mockLogic.On("Redeliver", mock.Anything, 1, 5).Return(&entity.WebhookDelivery{ID: 5}, nil)
+	policy := logic.NewWebhookPolicy(mockLogic, rules)
+
+	_, err := policy.GetAll(admin)
+	assert.NoError(t, err)
+	_, err = policy.Redeliver(admin, 1, 5)
+	assert.NoError(t, err)
+	_, err = policy.Create(user, &entity.Webhook{})
+	assert.Equal(t, serverErr.ErrForbidden, err)
+	_, err = policy.GetDeliveries(user, 1)
+	assert.Equal(t, serverErr.ErrForbidden, err)
+	assert.Equal(t, serverErr.ErrForbidden, policy.Delete(context.TODO(), 1))
+	mockLogic.AssertExpectations(t)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const namespace = "person_api"
+
+// Metrics holds the collectors of the service on a registry of its own, so that every instance,
+// those of tests included, starts from zero.
+type Metrics struct {
+	Registry         *prometheus.Registry
+	RequestDuration  *prometheus.HistogramVec
+	RequestsInFlight prometheus.Gauge
+	QueryDuration    *prometheus.HistogramVec
+	PersonsCreated   prometheus.Counter
+	PersonsDeleted   prometheus.Counter
+	CacheRequests    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		RequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests being served.",
+		}),
+		QueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of repository calls by repository, method and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repository", "method", "outcome"}),
+		PersonsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "persons_created_total",
+			Help:      "Persons created.",
+		}),
+		PersonsDeleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "persons_deleted_total",
+			Help:      "Persons deleted.",
+		}),
+		CacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Cache lookups by cache and result, hit or miss.",
+		}, []string{"cache", "result"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.RequestDuration,
+		m.RequestsInFlight,
+		m.QueryDuration,
+		m.PersonsCreated,
+		m.PersonsDeleted,
+		m.CacheRequests,
+	)
+	return m
+}
+
+// RegisterPool exposes the statistics of pool under the label name.
+func (m *Metrics) RegisterPool(name string, pool *pgxpool.Pool) {
+	m.Registry.MustRegister(NewPoolCollector(name, func() PoolStat { return pool.Stat() }))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveQuery records a repository call started at start.
+func (m *Metrics) ObserveQuery(repository, method string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.QueryDuration.WithLabelValues(repository, method, outcome).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCache counts a lookup in cache.
+func (m *Metrics) ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.CacheRequests.WithLabelValues(cache, result).Inc()
+}
@@ -0,0 +1,71 @@
+package metrics_test
+
+import (
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_ObserveQuery(t *testing.T) {
+	m := metrics.New()
+	m.ObserveQuery("person", "GetAll", time.Now(), nil)
+	m.ObserveQuery("person", "GetAll", time.Now(), nil)
+	m.ObserveQuery("person", "Create", time.Now(), errors.New("boom"))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.QueryDuration))
+	expected := `
+# HELP person_api_persons_created_total Persons created.
+# TYPE person_api_persons_created_total counter
+person_api_persons_created_total 0
+`
+	require.NoError(t, testutil.CollectAndCompare(m.PersonsCreated, strings.NewReader(expected)))
+}
+
+func TestPoolCollector(t *testing.T) {
+	stat := new(mocks.PoolStat)
+	stat.On("AcquiredConns").Return(int32(3))
+	stat.On("IdleConns").Return(int32(2))
+	stat.On("TotalConns").Return(int32(5))
+	stat.On("MaxConns").Return(int32(10))
+	stat.On("AcquireCount").Return(int64(100))
+	stat.On("EmptyAcquireCount").Return(int64(7))
+	stat.On("CanceledAcquireCount").Return(int64(1))
+	stat.On("AcquireDuration").Return(1500 * time.Millisecond)
+	collector := metrics.NewPoolCollector("primary", func() metrics.PoolStat { return stat })
+
+	expected := `
+# HELP person_api_db_pool_acquired_connections Connections currently acquired from the pool.
+# TYPE person_api_db_pool_acquired_connections gauge
+person_api_db_pool_acquired_connections{pool="primary"} 3
+# HELP person_api_db_pool_waits_total Acquires that had to wait for a connection.
+# TYPE person_api_db_pool_waits_total counter
+person_api_db_pool_waits_total{pool="primary"} 7
+# HELP person_api_db_pool_acquire_duration_seconds_total Time spent acquiring connections.
+# TYPE person_api_db_pool_acquire_duration_seconds_total counter
+person_api_db_pool_acquire_duration_seconds_total{pool="primary"} 1.5
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"person_api_db_pool_acquired_connections",
+		"person_api_db_pool_waits_total",
+		"person_api_db_pool_acquire_duration_seconds_total"))
+	assert.Equal(t, 8, testutil.CollectAndCount(collector))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := metrics.New()
+	m.PersonsDeleted.Inc()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "person_api_persons_deleted_total 1")
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+}
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"io"
+	"sync"
+)
+
+// Publisher delivers an event to its consumers. It may be called again with an event it has delivered
+// already, consumers are expected to drop duplicates by the event id.
+type Publisher interface {
+	Publish(ctx context.Context, event *entity.Event) error
+}
+
+// WriterPublisher writes every event as a line of JSON, to stdout or a file for local use.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, event *entity.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(data, '\n'))
+	return err
+}
+
+// MultiPublisher publishes every event to each of its publishers in turn. An event failing with one of them
+// is published again to all, so each should tolerate duplicates.
+type MultiPublisher []Publisher
+
+func (p MultiPublisher) Publish(ctx context.Context, event *entity.Event) error {
+	for _, publisher := range p {
+		err := publisher.Publish(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const cleanupPeriod = time.Minute
+
+// Config tunes the Relay. Publish gives up on an event after PublishTimeout, and an event that failed
+// MaxAttempts times is failed for good. Retention is how long published events are kept, 0 keeps them.
+type Config struct {
+	BatchSize      int
+	Interval       time.Duration
+	Retention      time.Duration
+	PublishTimeout time.Duration
+	MaxAttempts    int
+}
+
+// Relay publishes the events of the outbox in the order they were written. An event is marked published
+// only after Publisher accepted it, so it is delivered at least once. When an event fails, the later
+// events of the same person wait for the next attempt, until the event fails for good.
+type Relay struct {
+	Outbox    entity.OutboxRepository
+	Publisher Publisher
+	Config    Config
+}
+
+func NewRelay(outbox entity.OutboxRepository, publisher Publisher, config Config) *Relay {
+	return &Relay{Outbox: outbox, Publisher: publisher, Config: config}
+}
+
+// RelayOnce publishes one batch of events and returns the number of events published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	var published int
+	_, err := r.Outbox.Process(ctx, r.Config.BatchSize, func(ctx context.Context, events []*entity.Event) map[int]error {
+		results := r.publish(ctx, events)
+		for _, err := range results {
+			if err == nil {
+				published++
+			}
+		}
+		return results
+	})
+	return published, err
+}
+
+func (r *Relay) publish(ctx context.Context, events []*entity.Event) map[int]error {
+	results := make(map[int]error, len(events))
+	blocked := make(map[int]bool)
+	for _, event := range events {
+		if ctx.Err() != nil {
+			// the events left are passed again to the next relay, not failed
+			break
+		}
+		if blocked[event.PersonID] {
+			continue
+		}
+		err := r.publishOne(ctx, event)
+		if err == nil {
+			results[event.ID] = nil
+			continue
+		}
+		blocked[event.PersonID] = true
+		entry := logger.FromContext(ctx).WithFields(logrus.Fields{
+			"Event":    event.ID,
+			"Type":     event.Type,
+			"Attempts": event.Attempts + 1,
+			"Error":    err,
+		})
+		if r.Config.MaxAttempts > 0 && event.Attempts+1 >= r.Config.MaxAttempts {
+			// the later events of the person are published without it, someone has to look at it
+			entry.Error("outbox: event failed, it is not published again")
+			err = fmt.Errorf("%w: %v", entity.ErrEventFailed, err)
+		} else {
+			entry.Warning("outbox: publish failed")
+		}
+		results[event.ID] = err
+	}
+	return results
+}
+
+// publishOne publishes event within PublishTimeout, so that a hanging publisher does not hold the outbox lock.
+func (r *Relay) publishOne(ctx context.Context, event *entity.Event) error {
+	if r.Config.PublishTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Config.PublishTimeout)
+		defer cancel()
+	}
+	return r.Publisher.Publish(ctx, event)
+}
+
+// Run relays the outbox every Interval, batch after batch while whole batches are published, until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Config.Interval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for ctx.Err() == nil {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				logger.FromContext(ctx).WithField("Error", err).Error("outbox: relay")
+			}
+			if err != nil || n < r.Config.BatchSize {
+				break
+			}
+		}
+		if r.Config.Retention > 0 && time.Since(lastCleanup) > cleanupPeriod {
+			lastCleanup = time.Now()
+			n, err := r.Outbox.DeletePublished(ctx, lastCleanup.Add(-r.Config.Retention))
+			if err != nil {
+				logger.FromContext(ctx).WithField("Error", err).Error("outbox: cleanup")
+			} else if n > 0 {
+				logger.FromContext(ctx).WithField("Rows", n).Debug("outbox: deleted published events")
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package outbox_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/outbox"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var (
+	created  = &entity.Event{ID: 1, Type: entity.PersonCreated, PersonID: 1, After: &entity.Person{ID: 1, FirstName: "test"}}
+	created2 = &entity.Event{ID: 2, Type: entity.PersonCreated, PersonID: 2, After: &entity.Person{ID: 2, FirstName: "test2"}}
+	updated  = &entity.Event{ID: 3, Type: entity.PersonUpdated, PersonID: 1, Before: &entity.Person{ID: 1, FirstName: "test"}, After: &entity.Person{ID: 1, FirstName: "changed"}}
+	deleted2 = &entity.Event{ID: 4, Type: entity.PersonDeleted, PersonID: 2, Before: &entity.Person{ID: 2, FirstName: "test2"}}
+	retried  = &entity.Event{ID: 5, Type: entity.PersonCreated, PersonID: 3, After: &entity.Person{ID: 3, FirstName: "test3"}, Attempts: 2}
+)
+
+// processWith makes the outbox mock pass events to the relay and keep its results.
+func processWith(mockOutbox *mocks.OutboxRepository, events []*entity.Event, results *map[int]error) {
+	mockOutbox.On("Process", mock.Anything, 10, mock.Anything).
+		Return(func(ctx context.Context, _ int, process func(context.Context, []*entity.Event) map[int]error) (int, error) {
+			*results = process(ctx, events)
+			return len(events), nil
+		}).Once()
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	errPublish := errors.New("receiver down")
+	tests := []struct {
+		name          string
+		events        []*entity.Event
+		mockFunc      func(mockPublisher *mocks.Publisher)
+		waitResults   map[int]error
+		waitPublished int
+	}{
+		{
+			name:   "in order",
+			events: []*entity.Event{created, created2, updated, deleted2},
+			mockFunc: func(mockPublisher *mocks.Publisher) {
+				mockPublisher.On("Publish", mock.Anything, mock.Anything).Return(nil).Times(4)
+			},
+			waitResults:   map[int]error{1: nil, 2: nil, 3: nil, 4: nil},
+			waitPublished: 4,
+		},
+		{
+			name:   "failure holds back the later events of the person",
+			events: []*entity.Event{created, created2, updated, deleted2},
+			mockFunc: func(mockPublisher *mocks.Publisher) {
+				mockPublisher.On("Publish", mock.Anything, created).Return(errPublish).Once()
+				mockPublisher.On("Publish", mock.Anything, created2).Return(nil).Once()
+				mockPublisher.On("Publish", mock.Anything, deleted2).Return(nil).Once()
+			},
+			waitResults:   map[int]error{1: errPublish, 2: nil, 4: nil},
+			waitPublished: 2,
+		},
+		{
+			name:   "last attempt fails the event",
+			events: []*entity.Event{retried, created2},
+			mockFunc: func(mockPublisher *mocks.Publisher) {
+				mockPublisher.On("Publish", mock.Anything, retried).Return(errPublish).Once()
+				mockPublisher.On("Publish", mock.Anything, created2).Return(nil).Once()
+			},
+			waitResults:   map[int]error{5: fmt.Errorf("%w: %v", entity.ErrEventFailed, errPublish), 2: nil},
+			waitPublished: 1,
+		},
+		{
+			name:          "empty outbox",
+			mockFunc:      func(mockPublisher *mocks.Publisher) {},
+			waitResults:   map[int]error{},
+			waitPublished: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockOutbox := new(mocks.OutboxRepository)
+			mockPublisher := new(mocks.Publisher)
+			test.mockFunc(mockPublisher)
+			var results map[int]error
+			processWith(mockOutbox, test.events, &results)
+
+			relay := outbox.NewRelay(mockOutbox, mockPublisher, outbox.Config{BatchSize: 10, Interval: time.Second, MaxAttempts: 3})
+			published, err := relay.RelayOnce(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, test.waitPublished, published)
+			assert.Equal(t, test.waitResults, results)
+			mockOutbox.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRelay_PublishTimeout(t *testing.T) {
+	mockOutbox := new(mocks.OutboxRepository)
+	mockPublisher := new(mocks.Publisher)
+	mockPublisher.On("Publish", mock.Anything, created).Return(func(ctx context.Context, _ *entity.Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}).Once()
+	var results map[int]error
+	processWith(mockOutbox, []*entity.Event{created}, &results)
+
+	relay := outbox.NewRelay(mockOutbox, mockPublisher, outbox.Config{BatchSize: 10, Interval: time.Second,
+		PublishTimeout: 10 * time.Millisecond, MaxAttempts: 3})
+	published, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.ErrorIs(t, results[created.ID], context.DeadlineExceeded)
+	assert.NotErrorIs(t, results[created.ID], entity.ErrEventFailed)
+}
+
+func TestRelay_BatchDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockOutbox := new(mocks.OutboxRepository)
+	mockPublisher := new(mocks.Publisher)
+	mockPublisher.On("Publish", mock.Anything, created).Return(nil).Run(func(mock.Arguments) {
+		cancel()
+	}).Once()
+	var results map[int]error
+	processWith(mockOutbox, []*entity.Event{created, created2}, &results)
+
+	relay := outbox.NewRelay(mockOutbox, mockPublisher, outbox.Config{BatchSize: 10, Interval: time.Second, MaxAttempts: 1})
+	published, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	// the event left is neither published nor failed
+	assert.Equal(t, map[int]error{created.ID: nil}, results)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestRelay_Run(t *testing.T) {
+	mockOutbox := new(mocks.OutboxRepository)
+	publisher := &recordingPublisher{published: make(chan *entity.Event, 10)}
+	var results map[int]error
+	processWith(mockOutbox, []*entity.Event{created, updated}, &results)
+	mockOutbox.On("Process", mock.Anything, 10, mock.Anything).Return(0, nil)
+	cleaned := make(chan struct{}, 1)
+	mockOutbox.On("DeletePublished", mock.Anything, mock.Anything).Return(1, nil).Run(func(mock.Arguments) {
+		select {
+		case cleaned <- struct{}{}:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relay := outbox.NewRelay(mockOutbox, publisher, outbox.Config{BatchSize: 10, Interval: 10 * time.Millisecond, Retention: time.Hour})
+	go relay.Run(ctx)
+	for _, want := range []*entity.Event{created, updated} {
+		select {
+		case event := <-publisher.published:
+			assert.Equal(t, want, event)
+		case <-time.After(time.Second):
+			t.Fatal("event not published")
+		}
+	}
+	select {
+	case <-cleaned:
+	case <-time.After(time.Second):
+		t.Fatal("published events not deleted")
+	}
+}
+
+type recordingPublisher struct {
+	published chan *entity.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event *entity.Event) error {
+	p.published <- event
+	return nil
+}
+
+func TestWriterPublisher(t *testing.T) {
+	buf := new(bytes.Buffer)
+	publisher := outbox.NewWriterPublisher(buf)
+	require.NoError(t, publisher.Publish(context.Background(), created))
+	require.NoError(t, publisher.Publish(context.Background(), updated))
+
+	scanner := bufio.NewScanner(buf)
+	var lines []map[string]interface{}
+	for scanner.Scan() {
+		line := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "PersonCreated", lines[0]["type"])
+	assert.Nil(t, lines[0]["before"])
+	assert.Equal(t, "PersonUpdated", lines[1]["type"])
+	assert.Equal(t, map[string]interface{}{"id": 1.0, "first_name": "changed"}, lines[1]["after"])
+}
+
+func TestMultiPublisher(t *testing.T) {
+	errPublish := errors.New("receiver down")
+	first := new(mocks.Publisher)
+	second := new(mocks.Publisher)
+	third := new(mocks.Publisher)
+	first.On("Publish", mock.Anything, created).Return(nil)
+	second.On("Publish", mock.Anything, created).Return(errPublish)
+
+	err := outbox.MultiPublisher{first, second, third}.Publish(context.Background(), created)
+	assert.Equal(t, errPublish, err)
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+	third.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
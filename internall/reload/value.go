@@ -0,0 +1,22 @@
+package reload
+
+import "sync/atomic"
+
+// Value holds a setting that can change while the service runs, safe for concurrent use.
+type Value[T any] struct {
+	v atomic.Pointer[T]
+}
+
+func NewValue[T any](value T) *Value[T] {
+	v := new(Value[T])
+	v.Store(value)
+	return v
+}
+
+func (v *Value[T]) Load() T {
+	return *v.v.Load()
+}
+
+func (v *Value[T]) Store(value T) {
+	v.v.Store(&value)
+}
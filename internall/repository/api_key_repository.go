@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) entity.APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func scanAPIKey(row pgx.Row, key *entity.APIKey) error {
+	return row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+}
+
+func (r *APIKeyRepository) getOneKey(ctx context.Context, query string, args ...interface{}) (*entity.APIKey, error) {
+	key := new(entity.APIKey)
+	err := scanAPIKey(r.db.QueryRow(ctx, query, args...), key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err, key = nil, nil
+	}
+	return key, err
+}
+
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]*entity.APIKey, error) {
+	sql := `SELECT id, name, prefix, hash, scopes, expires_at, last_used_at, revoked_at, created_at
+			FROM api_keys
+			ORDER BY id;`
+	keys := make([]*entity.APIKey, 0)
+	rows, err := r.db.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key := new(entity.APIKey)
+		err = scanAPIKey(rows, key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*entity.APIKey, error) {
+	sql := `SELECT id, name, prefix, hash, scopes, expires_at, last_used_at, revoked_at, created_at
+			FROM api_keys
+			WHERE id = $1;`
+	return r.getOneKey(ctx, sql, id)
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	sql := `SELECT id, name, prefix, hash, scopes, expires_at, last_used_at, revoked_at, created_at
+			FROM api_keys
+			WHERE hash = $1;`
+	return r.getOneKey(ctx, sql, hash)
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, req *entity.APIKey) (*entity.APIKey, error) {
+	sql := `INSERT INTO api_keys (name, prefix, hash, scopes, expires_at)
+			VALUES ($1,$2,$3,$4,$5)
+			RETURNING id, created_at;`
+	err := r.db.QueryRow(ctx, sql, req.Name, req.Prefix, req.Hash, req.Scopes, req.ExpiresAt).Scan(&req.ID, &req.CreatedAt)
+	return req, err
+}
+
+func (r *APIKeyRepository) Rotate(ctx context.Context, id int, prefix, hash string) error {
+	sql := `UPDATE api_keys
+			SET prefix = $1, hash = $2, last_used_at = NULL
+			WHERE id = $3 AND revoked_at IS NULL;`
+	return r.exec(ctx, sql, prefix, hash, id)
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	sql := `UPDATE api_keys
+			SET revoked_at = now()
+			WHERE id = $1 AND revoked_at IS NULL;`
+	return r.exec(ctx, sql, id)
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int) error {
+	sql := `UPDATE api_keys
+			SET last_used_at = now()
+			WHERE id = $1;`
+	return r.exec(ctx, sql, id)
+}
+
+func (r *APIKeyRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.Exec(ctx, query, args...)
+	if err == nil && result.RowsAffected() != 1 {
+		logger.FromContext(ctx).WithField("Rows", result.RowsAffected()).Debug("api key not updated")
+		err = serverErr.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func truncateAPIKeys(ctx context.Context, db *pgxpool.Pool) {
+	sql := `TRUNCATE api_keys RESTART IDENTITY;`
+	db.Exec(ctx, sql)
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	dbPoll := GetTestDb()
+	defer func() {
+		truncateAPIKeys(ctx, dbPoll)
+		dbPoll.Close()
+	}()
+	rep := repository.NewAPIKeyRepository(dbPoll)
+	created, err := rep.Create(ctx, &entity.APIKey{Name: "batch", Prefix: "rcrud_aaaaaaaa", Hash: "hash1", Scopes: []string{"person:read"}})
+	assert.NoError(t, err)
+	assert.NotZero(t, created.ID)
+
+	key, err := rep.GetByHash(ctx, "hash1")
+	assert.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, "batch", key.Name)
+	assert.Equal(t, []string{"person:read"}, key.Scopes)
+
+	assert.NoError(t, rep.TouchLastUsed(ctx, created.ID))
+	assert.NoError(t, rep.Rotate(ctx, created.ID, "rcrud_bbbbbbbb", "hash2"))
+	key, err = rep.GetByHash(ctx, "hash1")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+	key, err = rep.GetByID(ctx, created.ID)
+	assert.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, "rcrud_bbbbbbbb", key.Prefix)
+	assert.Nil(t, key.LastUsedAt)
+
+	assert.NoError(t, rep.Revoke(ctx, created.ID))
+	assert.Equal(t, serverErr.ErrNotFound, rep.Revoke(ctx, created.ID))
+	assert.Equal(t, serverErr.ErrNotFound, rep.Rotate(ctx, created.ID, "rcrud_cccccccc", "hash3"))
+	keys, err := rep.GetAll(ctx)
+	assert.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].RevokedAt)
+}
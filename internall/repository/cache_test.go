@@ -0,0 +1,37 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := repository.NewMemoryCache(2)
+	cache.Set(ctx, "a", []byte("1"), 0)
+	cache.Set(ctx, "b", []byte("2"), 0)
+	// reading a makes b the least recently used entry
+	value, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	cache.Set(ctx, "c", []byte("3"), 0)
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get(ctx, "b")
+	assert.False(t, ok)
+
+	cache.Set(ctx, "a", []byte("4"), 0)
+	value, _ = cache.Get(ctx, "a")
+	assert.Equal(t, []byte("4"), value)
+	cache.Delete(ctx, "a")
+	_, ok = cache.Get(ctx, "a")
+	assert.False(t, ok)
+
+	cache.Set(ctx, "d", []byte("5"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	_, ok = cache.Get(ctx, "d")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"golang.org/x/sync/singleflight"
+	"strconv"
+	"time"
+)
+
+const (
+	personCacheName = "person"
+	// generationKey holds the version of every list and count key.
+	generationKey = "persons:generation"
+	// sharedLoadTimeout bounds a load shared by concurrent misses when its first caller has no deadline.
+	sharedLoadTimeout = 10 * time.Second
+)
+
+// CachedPersonRepository serves reads of Rep from Cache for TTL. Persons are cached whole by id and cut down
+// to the requested fields, concurrent misses of a key load it once. Reads that must see the primary bypass
+// the cache and refresh it. Every key carries a version that writes replace, so that a load that was in flight
+// during a write caches its old value under a key that is never read again.
+type CachedPersonRepository struct {
+	Rep     entity.PersonRepository
+	Cache   CacheBackend
+	TTL     time.Duration
+	Metrics *metrics.Metrics
+	group   singleflight.Group
+}
+
+// NewCachedPersonRepository takes nil metrics when metrics are disabled.
+func NewCachedPersonRepository(rep entity.PersonRepository, cache CacheBackend, ttl time.Duration, metrics *metrics.Metrics) entity.PersonRepository {
+	return &CachedPersonRepository{Rep: rep, Cache: cache, TTL: ttl, Metrics: metrics}
+}
+
+func (r *CachedPersonRepository) observe(hit bool) {
+	if r.Metrics != nil {
+		r.Metrics.ObserveCache(personCacheName, hit)
+	}
+}
+
+// load decodes the value under key into target, loading and caching it on a miss.
+func (r *CachedPersonRepository) load(ctx context.Context, key string, target interface{}, load func(ctx context.Context) (interface{}, error)) error {
+	if !consistency.PrimaryRequired(ctx) {
+		data, ok := r.Cache.Get(ctx, key)
+		if ok && json.Unmarshal(data, target) == nil {
+			r.observe(true)
+			return nil
+		}
+	}
+	r.observe(false)
+	flight := key
+	if consistency.PrimaryRequired(ctx) {
+		// a replica read in flight must not answer a read that has to see the primary
+		flight = "primary|" + key
+	}
+	flightResult := r.group.DoChan(flight, func() (interface{}, error) {
+		// the load is shared, the caller starting it going away must not fail the others
+		ctx, cancel := sharedContext(ctx)
+		defer cancel()
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		r.Cache.Set(ctx, key, data, r.TTL)
+		return data, nil
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-flightResult:
+		if result.Err != nil {
+			return result.Err
+		}
+		// every caller decodes a copy of its own
+		return json.Unmarshal(result.Val.([]byte), target)
+	}
+}
+
+// detachedContext keeps the values of a context, its logger and trace, without its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// sharedContext detaches ctx, leaving it the time ctx had left.
+func sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := sharedLoadTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return context.WithTimeout(detachedContext{ctx}, timeout)
+}
+
+func personVersionKey(id int) string {
+	return "persons:version:" + strconv.Itoa(id)
+}
+
+func (r *CachedPersonRepository) personKey(ctx context.Context, id int) string {
+	return "persons:id:" + strconv.Itoa(id) + ":" + r.version(ctx, personVersionKey(id))
+}
+
+// version returns the token under key, a new one when there is none.
+func (r *CachedPersonRepository) version(ctx context.Context, key string) string {
+	version, ok := r.Cache.Get(ctx, key)
+	if !ok {
+		return r.invalidate(ctx, key)
+	}
+	return string(version)
+}
+
+// invalidate replaces the token under key, the values cached with the old one are never read again and expire.
+func (r *CachedPersonRepository) invalidate(ctx context.Context, key string) string {
+	token := make([]byte, 8)
+	_, err := rand.Read(token)
+	if err != nil {
+		logger.FromContext(ctx).WithField("Error", err).Error("person cache version")
+	}
+	version := hex.EncodeToString(token)
+	r.Cache.Set(ctx, key, []byte(version), 0)
+	return version
+}
+
+func (r *CachedPersonRepository) listKey(ctx context.Context, method string, args ...interface{}) string {
+	encoded, _ := json.Marshal(args)
+	return "persons:" + r.version(ctx, generationKey) + ":" + method + ":" + string(encoded)
+}
+
+func (r *CachedPersonRepository) getPersons(ctx context.Context, key string, load func(ctx context.Context) ([]*entity.Person, error)) ([]*entity.Person, error) {
+	var persons []*entity.Person
+	err := r.load(ctx, key, &persons, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	return persons, err
+}
+
+func (r *CachedPersonRepository) count(ctx context.Context, key string, load func(ctx context.Context) (int, error)) (int, error) {
+	var count int
+	err := r.load(ctx, key, &count, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	return count, err
+}
+
+func (r *CachedPersonRepository) GetAll(ctx context.Context, fields []string, limit, offset int) ([]*entity.Person, error) {
+	return r.getPersons(ctx, r.listKey(ctx, "GetAll", fields, limit, offset), func(ctx context.Context) ([]*entity.Person, error) {
+		return r.Rep.GetAll(ctx, fields, limit, offset)
+	})
+}
+
+func (r *CachedPersonRepository) GetAllByEmail(ctx context.Context, email string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	return r.getPersons(ctx, r.listKey(ctx, "GetAllByEmail", email, fields, limit, offset), func(ctx context.Context) ([]*entity.Person, error) {
+		return r.Rep.GetAllByEmail(ctx, email, fields, limit, offset)
+	})
+}
+
+func (r *CachedPersonRepository) GetAllByPhone(ctx context.Context, phone string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	return r.getPersons(ctx, r.listKey(ctx, "GetAllByPhone", phone, fields, limit, offset), func(ctx context.Context) ([]*entity.Person, error) {
+		return r.Rep.GetAllByPhone(ctx, phone, fields, limit, offset)
+	})
+}
+
+func (r *CachedPersonRepository) GetAllByName(ctx context.Context, firstName string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	return r.getPersons(ctx, r.listKey(ctx, "GetAllByName", firstName, fields, limit, offset), func(ctx context.Context) ([]*entity.Person, error) {
+		return r.Rep.GetAllByName(ctx, firstName, fields, limit, offset)
+	})
+}
+
+func (r *CachedPersonRepository) GetByID(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	var person *entity.Person
+	err := r.load(ctx, r.personKey(ctx, id), &person, func(ctx context.Context) (interface{}, error) {
+		return r.Rep.GetByID(ctx, id, nil)
+	})
+	if err != nil || person == nil {
+		return nil, err
+	}
+	return project(person, fields), nil
+}
+
+// project returns the columns of fields of person, as PersonRepository selects them.
+func project(person *entity.Person, fields []string) *entity.Person {
+	result := new(entity.Person)
+	for _, column := range columns(fields) {
+		switch column {
+		case "id":
+			result.ID = person.ID
+		case "email":
+			result.Email = person.Email
+		case "phone":
+			result.Phone = person.Phone
+		case "first_name":
+			result.FirstName = person.FirstName
+		case "updated_at":
+			result.UpdatedAt = person.UpdatedAt
+		}
+	}
+	return result
+}
+
+// GetByEmail is not cached, it checks for conflicts before writes.
+func (r *CachedPersonRepository) GetByEmail(ctx context.Context, email string) (*entity.Person, error) {
+	return r.Rep.GetByEmail(ctx, email)
+}
+
+func (r *CachedPersonRepository) Create(ctx context.Context, req *entity.Person) (*entity.Person, error) {
+	person, err := r.Rep.Create(ctx, req)
+	if err == nil {
+		// a lookup before the person existed may have cached its absence
+		r.invalidate(ctx, personVersionKey(person.ID))
+	}
+	r.invalidate(ctx, generationKey)
+	return person, err
+}
+
+func (r *CachedPersonRepository) Update(ctx context.Context, id int, req *entity.Person) (*entity.Person, error) {
+	person, err := r.Rep.Update(ctx, id, req)
+	r.invalidate(ctx, personVersionKey(id))
+	r.invalidate(ctx, generationKey)
+	return person, err
+}
+
+func (r *CachedPersonRepository) Delete(ctx context.Context, id int) error {
+	err := r.Rep.Delete(ctx, id)
+	r.invalidate(ctx, personVersionKey(id))
+	r.invalidate(ctx, generationKey)
+	return err
+}
+
+func (r *CachedPersonRepository) CountAll(ctx context.Context) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "CountAll"), r.Rep.CountAll)
+}
+
+func (r *CachedPersonRepository) CountAllByEmail(ctx context.Context, email string) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "CountAllByEmail", email), func(ctx context.Context) (int, error) {
+		return r.Rep.CountAllByEmail(ctx, email)
+	})
+}
+
+func (r *CachedPersonRepository) CountAllByPhone(ctx context.Context, phone string) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "CountAllByPhone", phone), func(ctx context.Context) (int, error) {
+		return r.Rep.CountAllByPhone(ctx, phone)
+	})
+}
+
+func (r *CachedPersonRepository) CountAllByName(ctx context.Context, name string) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "CountAllByName", name), func(ctx context.Context) (int, error) {
+		return r.Rep.CountAllByName(ctx, name)
+	})
+}
+
+func (r *CachedPersonRepository) EstimateCountAll(ctx context.Context) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "EstimateCountAll"), r.Rep.EstimateCountAll)
+}
+
+func (r *CachedPersonRepository) EstimateCountAllByEmail(ctx context.Context, email string) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "EstimateCountAllByEmail", email), func(ctx context.Context) (int, error) {
+		return r.Rep.EstimateCountAllByEmail(ctx, email)
+	})
+}
+
+func (r *CachedPersonRepository) EstimateCountAllByPhone(ctx context.Context, phone string) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "EstimateCountAllByPhone", phone), func(ctx context.Context) (int, error) {
+		return r.Rep.EstimateCountAllByPhone(ctx, phone)
+	})
+}
+
+func (r *CachedPersonRepository) EstimateCountAllByName(ctx context.Context, name string) (int, error) {
+	return r.count(ctx, r.listKey(ctx, "EstimateCountAllByName", name), func(ctx context.Context) (int, error) {
+		return r.Rep.EstimateCountAllByName(ctx, name)
+	})
+}
+
+func (r *CachedPersonRepository) ParseData(data []byte) (*entity.Person, error) {
+	return r.Rep.ParseData(data)
+}
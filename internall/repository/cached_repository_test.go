@@ -0,0 +1,214 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachedPersonRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New()
+	mockRep := new(mocks.PersonRepository)
+	updatedAt := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	cached := &entity.Person{ID: 1, Email: "test@test.ru", Phone: "1234", FirstName: "test", UpdatedAt: &updatedAt}
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Return(cached, nil).Once()
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Return(testPerson1, nil).Once()
+	mockRep.On("GetByID", mock.Anything, 2, []string(nil)).Return(nil, nil).Once()
+	mockRep.On("Update", mock.Anything, 1, testPerson1).Return(testPerson1, nil).Once()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, m)
+
+	person, err := rep.GetByID(ctx, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, cached, person)
+	person.FirstName = "changed by the caller"
+	person, err = rep.GetByID(ctx, 1, []string{"email"})
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.Person{ID: 1, Email: "test@test.ru", UpdatedAt: &updatedAt}, person)
+
+	// absent persons are cached as well
+	for i := 0; i < 2; i++ {
+		person, err = rep.GetByID(ctx, 2, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, person)
+	}
+
+	_, err = rep.Update(ctx, 1, testPerson1)
+	assert.NoError(t, err)
+	person, err = rep.GetByID(ctx, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testPerson1, person)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.CacheRequests.WithLabelValues("person", "hit")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.CacheRequests.WithLabelValues("person", "miss")))
+	mockRep.AssertExpectations(t)
+}
+
+func TestCachedPersonRepository_Lists(t *testing.T) {
+	ctx := context.Background()
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("GetAll", mock.Anything, []string(nil), 10, 0).Return([]*entity.Person{testPerson1}, nil).Once()
+	mockRep.On("GetAll", mock.Anything, []string(nil), 10, 0).Return([]*entity.Person{testPerson1, testPerson2}, nil).Once()
+	mockRep.On("GetAllByEmail", mock.Anything, "test@test.ru", []string{"email"}, 10, 0).Return([]*entity.Person{testPerson1}, nil).Once()
+	mockRep.On("CountAll", mock.Anything).Return(1, nil).Once()
+	mockRep.On("CountAll", mock.Anything).Return(2, nil).Once()
+	mockRep.On("Create", mock.Anything, testPerson2).Return(testPerson2, nil).Once()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, nil)
+
+	for i := 0; i < 2; i++ {
+		persons, err := rep.GetAll(ctx, nil, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.Person{testPerson1}, persons)
+		persons, err = rep.GetAllByEmail(ctx, "test@test.ru", []string{"email"}, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.Person{testPerson1}, persons)
+		count, err := rep.CountAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	}
+
+	_, err := rep.Create(ctx, testPerson2)
+	assert.NoError(t, err)
+	persons, err := rep.GetAll(ctx, nil, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.Person{testPerson1, testPerson2}, persons)
+	count, err := rep.CountAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	mockRep.AssertExpectations(t)
+}
+
+func TestCachedPersonRepository_Primary(t *testing.T) {
+	ctx := consistency.WithPrimary(context.Background())
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Return(testPerson1, nil).Twice()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := rep.GetByID(ctx, 1, nil)
+		assert.NoError(t, err)
+	}
+	// the primary read refreshed the cache for the other reads
+	person, err := rep.GetByID(context.Background(), 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testPerson1, person)
+	mockRep.AssertExpectations(t)
+}
+
+func TestCachedPersonRepository_Singleflight(t *testing.T) {
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).After(50*time.Millisecond).Return(testPerson1, nil).Once()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			person, err := rep.GetByID(context.Background(), 1, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, testPerson1, person)
+		}()
+	}
+	wg.Wait()
+	mockRep.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestCachedPersonRepository_StaleLoad(t *testing.T) {
+	ctx := context.Background()
+	updated := &entity.Person{ID: 1, Email: "new@test.ru", Phone: "1234", FirstName: "test"}
+	started, release := make(chan struct{}), make(chan struct{})
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	}).Return(testPerson1, nil).Once()
+	mockRep.On("Update", mock.Anything, 1, updated).Return(updated, nil).Once()
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Return(updated, nil).Once()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := rep.GetByID(ctx, 1, nil)
+		assert.NoError(t, err)
+	}()
+	<-started
+	_, err := rep.Update(ctx, 1, updated)
+	assert.NoError(t, err)
+	close(release)
+	<-done
+
+	// the row loaded before the update is not served from the cache
+	person, err := rep.GetByID(ctx, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, person)
+	mockRep.AssertExpectations(t)
+}
+
+func TestCachedPersonRepository_PrimaryFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	}).Return(testPerson1, nil).Once()
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Return(testPerson2, nil).Once()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := rep.GetByID(context.Background(), 1, nil)
+		assert.NoError(t, err)
+	}()
+	<-started
+	// the primary read does not wait for the replica read in flight
+	person, err := rep.GetByID(consistency.WithPrimary(context.Background()), 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testPerson2, person)
+	close(release)
+	<-done
+	mockRep.AssertExpectations(t)
+}
+
+func TestCachedPersonRepository_CanceledCaller(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("GetByID", mock.Anything, 1, []string(nil)).Run(func(args mock.Arguments) {
+		close(started)
+		<-release
+		// the shared load outlives the caller that started it
+		assert.NoError(t, args.Get(0).(context.Context).Err())
+	}).Return(testPerson1, nil).Once()
+	rep := repository.NewCachedPersonRepository(mockRep, repository.NewMemoryCache(100), time.Minute, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := rep.GetByID(ctx, 1, nil)
+		canceled <- err
+	}()
+	<-started
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+
+	waiting := make(chan *entity.Person)
+	go func() {
+		person, err := rep.GetByID(context.Background(), 1, nil)
+		assert.NoError(t, err)
+		waiting <- person
+	}()
+	close(release)
+	assert.Equal(t, testPerson1, <-waiting)
+	mockRep.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Querier runs the statements of the repositories, *pgxpool.Pool implements it.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+type DBPool interface {
+	Querier
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Ping(ctx context.Context) error
+}
+
+type replica struct {
+	pool    DBPool
+	healthy atomic.Bool
+}
+
+// DBRouter sends writes to the primary and spreads reads over the healthy replicas in turn.
+// Reads go to the primary as well when their context asks for it or no replica is healthy.
+type DBRouter struct {
+	primary  DBPool
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// NewDBRouter starts with every replica ejected until CheckReplicas finds it healthy.
+func NewDBRouter(primary DBPool, replicas ...DBPool) *DBRouter {
+	r := &DBRouter{primary: primary}
+	for _, pool := range replicas {
+		r.replicas = append(r.replicas, &replica{pool: pool})
+	}
+	return r
+}
+
+func (r *DBRouter) Primary() Querier {
+	return r.primary
+}
+
+// InTx runs fn in a transaction on the primary, committed when fn returns nil and rolled back otherwise.
+func (r *DBRouter) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.primary.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *DBRouter) Reader(ctx context.Context) Querier {
+	if len(r.replicas) == 0 || consistency.PrimaryRequired(ctx) {
+		return r.primary
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep.pool
+		}
+	}
+	return r.primary
+}
+
+// CheckReplicas pings every replica within timeout, ejecting the ones that fail and taking back the ones that answer.
+func (r *DBRouter) CheckReplicas(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for i, rep := range r.replicas {
+		wg.Add(1)
+		go func(i int, rep *replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := rep.pool.Ping(ctx)
+			healthy := err == nil
+			if rep.healthy.Swap(healthy) != healthy {
+				entry := logrus.WithField("replica", i)
+				if healthy {
+					entry.Info("database replica healthy")
+				} else {
+					entry.WithField("Error", err).Warning("database replica ejected")
+				}
+			}
+		}(i, rep)
+	}
+	wg.Wait()
+}
+
+// Watch checks the replicas every period until ctx is done.
+func (r *DBRouter) Watch(ctx context.Context, period, timeout time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.CheckReplicas(ctx, timeout)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/consistency"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestDBRouter(t *testing.T) {
+	primary := new(mocks.DBPool)
+	replicaA := new(mocks.DBPool)
+	replicaB := new(mocks.DBPool)
+	router := repository.NewDBRouter(primary, replicaA, replicaB)
+	ctx := context.Background()
+
+	// replicas are ejected until checked
+	assert.Same(t, primary, router.Reader(ctx))
+
+	replicaA.On("Ping", mock.Anything).Return(nil).Once()
+	replicaB.On("Ping", mock.Anything).Return(nil).Once()
+	router.CheckReplicas(ctx, time.Second)
+	first, second := router.Reader(ctx), router.Reader(ctx)
+	assert.ElementsMatch(t, []interface{}{replicaA, replicaB}, []interface{}{first, second})
+	assert.Same(t, first, router.Reader(ctx))
+	assert.Same(t, primary, router.Primary())
+	assert.Same(t, primary, router.Reader(consistency.WithPrimary(ctx)))
+
+	replicaA.On("Ping", mock.Anything).Return(errors.New("connection refused")).Once()
+	replicaB.On("Ping", mock.Anything).Return(nil).Once()
+	router.CheckReplicas(ctx, time.Second)
+	for i := 0; i < 3; i++ {
+		assert.Same(t, replicaB, router.Reader(ctx))
+	}
+
+	replicaA.On("Ping", mock.Anything).Return(errors.New("connection refused")).Once()
+	replicaB.On("Ping", mock.Anything).Return(errors.New("connection refused")).Once()
+	router.CheckReplicas(ctx, time.Second)
+	assert.Same(t, primary, router.Reader(ctx))
+
+	replicaA.AssertExpectations(t)
+	replicaB.AssertExpectations(t)
+}
+
+func TestDBRouter_WithoutReplicas(t *testing.T) {
+	primary := new(mocks.DBPool)
+	router := repository.NewDBRouter(primary)
+	router.CheckReplicas(context.Background(), time.Second)
+	assert.Same(t, primary, router.Reader(context.Background()))
+	primary.AssertNotCalled(t, "Ping", mock.Anything)
+}
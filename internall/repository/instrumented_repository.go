@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"time"
+)
+
+// InstrumentedPersonRepository records the duration of every call of Rep and counts the persons created and deleted.
+type InstrumentedPersonRepository struct {
+	Rep     entity.PersonRepository
+	Metrics *metrics.Metrics
+}
+
+func NewInstrumentedPersonRepository(rep entity.PersonRepository, metrics *metrics.Metrics) entity.PersonRepository {
+	return &InstrumentedPersonRepository{rep, metrics}
+}
+
+func (r *InstrumentedPersonRepository) observe(method string, start time.Time, err error) {
+	r.Metrics.ObserveQuery("person", method, start, err)
+}
+
+func (r *InstrumentedPersonRepository) GetAll(ctx context.Context, fields []string, limit, offset int) ([]*entity.Person, error) {
+	start := time.Now()
+	persons, err := r.Rep.GetAll(ctx, fields, limit, offset)
+	r.observe("GetAll", start, err)
+	return persons, err
+}
+
+func (r *InstrumentedPersonRepository) GetAllByEmail(ctx context.Context, email string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	start := time.Now()
+	persons, err := r.Rep.GetAllByEmail(ctx, email, fields, limit, offset)
+	r.observe("GetAllByEmail", start, err)
+	return persons, err
+}
+
+func (r *InstrumentedPersonRepository) GetAllByPhone(ctx context.Context, phone string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	start := time.Now()
+	persons, err := r.Rep.GetAllByPhone(ctx, phone, fields, limit, offset)
+	r.observe("GetAllByPhone", start, err)
+	return persons, err
+}
+
+func (r *InstrumentedPersonRepository) GetAllByName(ctx context.Context, firstName string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	start := time.Now()
+	persons, err := r.Rep.GetAllByName(ctx, firstName, fields, limit, offset)
+	r.observe("GetAllByName", start, err)
+	return persons, err
+}
+
+func (r *InstrumentedPersonRepository) GetByID(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	start := time.Now()
+	person, err := r.Rep.GetByID(ctx, id, fields)
+	r.observe("GetByID", start, err)
+	return person, err
+}
+
+func (r *InstrumentedPersonRepository) GetByEmail(ctx context.Context, email string) (*entity.Person, error) {
+	start := time.Now()
+	person, err := r.Rep.GetByEmail(ctx, email)
+	r.observe("GetByEmail", start, err)
+	return person, err
+}
+
+func (r *InstrumentedPersonRepository) Create(ctx context.Context, req *entity.Person) (*entity.Person, error) {
+	start := time.Now()
+	person, err := r.Rep.Create(ctx, req)
+	r.observe("Create", start, err)
+	if err == nil {
+		r.Metrics.PersonsCreated.Inc()
+	}
+	return person, err
+}
+
+func (r *InstrumentedPersonRepository) Update(ctx context.Context, id int, req *entity.Person) (*entity.Person, error) {
+	start := time.Now()
+	person, err := r.Rep.Update(ctx, id, req)
+	r.observe("Update", start, err)
+	return person, err
+}
+
+func (r *InstrumentedPersonRepository) Delete(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.Rep.Delete(ctx, id)
+	r.observe("Delete", start, err)
+	if err == nil {
+		r.Metrics.PersonsDeleted.Inc()
+	}
+	return err
+}
+
+func (r *InstrumentedPersonRepository) CountAll(ctx context.Context) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.CountAll(ctx)
+	r.observe("CountAll", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) CountAllByEmail(ctx context.Context, email string) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.CountAllByEmail(ctx, email)
+	r.observe("CountAllByEmail", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) CountAllByPhone(ctx context.Context, phone string) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.CountAllByPhone(ctx, phone)
+	r.observe("CountAllByPhone", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) CountAllByName(ctx context.Context, name string) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.CountAllByName(ctx, name)
+	r.observe("CountAllByName", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) EstimateCountAll(ctx context.Context) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.EstimateCountAll(ctx)
+	r.observe("EstimateCountAll", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) EstimateCountAllByEmail(ctx context.Context, email string) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.EstimateCountAllByEmail(ctx, email)
+	r.observe("EstimateCountAllByEmail", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) EstimateCountAllByPhone(ctx context.Context, phone string) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.EstimateCountAllByPhone(ctx, phone)
+	r.observe("EstimateCountAllByPhone", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) EstimateCountAllByName(ctx context.Context, name string) (int, error) {
+	start := time.Now()
+	count, err := r.Rep.EstimateCountAllByName(ctx, name)
+	r.observe("EstimateCountAllByName", start, err)
+	return count, err
+}
+
+func (r *InstrumentedPersonRepository) ParseData(data []byte) (*entity.Person, error) {
+	return r.Rep.ParseData(data)
+}
+
+// InstrumentedAPIKeyRepository records the duration of every call of Rep.
+type InstrumentedAPIKeyRepository struct {
+	Rep     entity.APIKeyRepository
+	Metrics *metrics.Metrics
+}
+
+func NewInstrumentedAPIKeyRepository(rep entity.APIKeyRepository, metrics *metrics.Metrics) entity.APIKeyRepository {
+	return &InstrumentedAPIKeyRepository{rep, metrics}
+}
+
+func (r *InstrumentedAPIKeyRepository) observe(method string, start time.Time, err error) {
+	r.Metrics.ObserveQuery("api_key", method, start, err)
+}
+
+func (r *InstrumentedAPIKeyRepository) GetAll(ctx context.Context) ([]*entity.APIKey, error) {
+	start := time.Now()
+	keys, err := r.Rep.GetAll(ctx)
+	r.observe("GetAll", start, err)
+	return keys, err
+}
+
+func (r *InstrumentedAPIKeyRepository) GetByID(ctx context.Context, id int) (*entity.APIKey, error) {
+	start := time.Now()
+	key, err := r.Rep.GetByID(ctx, id)
+	r.observe("GetByID", start, err)
+	return key, err
+}
+
+func (r *InstrumentedAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	start := time.Now()
+	key, err := r.Rep.GetByHash(ctx, hash)
+	r.observe("GetByHash", start, err)
+	return key, err
+}
+
+func (r *InstrumentedAPIKeyRepository) Create(ctx context.Context, req *entity.APIKey) (*entity.APIKey, error) {
+	start := time.Now()
+	key, err := r.Rep.Create(ctx, req)
+	r.observe("Create", start, err)
+	return key, err
+}
+
+func (r *InstrumentedAPIKeyRepository) Rotate(ctx context.Context, id int, prefix, hash string) error {
+	start := time.Now()
+	err := r.Rep.Rotate(ctx, id, prefix, hash)
+	r.observe("Rotate", start, err)
+	return err
+}
+
+func (r *InstrumentedAPIKeyRepository) Revoke(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.Rep.Revoke(ctx, id)
+	r.observe("Revoke", start, err)
+	return err
+}
+
+func (r *InstrumentedAPIKeyRepository) TouchLastUsed(ctx context.Context, id int) error {
+	start := time.Now()
+	err := r.Rep.TouchLastUsed(ctx, id)
+	r.observe("TouchLastUsed", start, err)
+	return err
+}
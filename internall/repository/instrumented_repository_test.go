@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/metrics"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestInstrumentedPersonRepository(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New()
+	mockRep := new(mocks.PersonRepository)
+	mockRep.On("Create", mock.Anything, testPerson1).Return(testPerson1, nil)
+	mockRep.On("Delete", mock.Anything, 1).Return(nil)
+	mockRep.On("Delete", mock.Anything, 2).Return(serverErr.ErrNotFound)
+	mockRep.On("GetAll", mock.Anything, []string(nil), 10, 0).Return([]*entity.Person{testPerson1}, nil)
+	rep := repository.NewInstrumentedPersonRepository(mockRep, m)
+
+	_, err := rep.Create(ctx, testPerson1)
+	assert.NoError(t, err)
+	assert.NoError(t, rep.Delete(ctx, 1))
+	assert.Equal(t, serverErr.ErrNotFound, rep.Delete(ctx, 2))
+	persons, err := rep.GetAll(ctx, nil, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.Person{testPerson1}, persons)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PersonsCreated))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PersonsDeleted))
+	// Create, GetAll and Delete by outcome
+	assert.Equal(t, 4, testutil.CollectAndCount(m.QueryDuration))
+	mockRep.AssertExpectations(t)
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type eventPayload struct {
+	Before *entity.Person `json:"before"`
+	After  *entity.Person `json:"after"`
+}
+
+// insertEvent records a change of the person in the outbox, tx is the transaction of the change.
+func insertEvent(ctx context.Context, tx pgx.Tx, eventType entity.EventType, personID int, before, after *entity.Person) error {
+	payload, err := json.Marshal(eventPayload{Before: before, After: after})
+	if err != nil {
+		return err
+	}
+	sql := `INSERT INTO outbox (type, person_id, payload)
+			VALUES ($1,$2,$3);`
+	_, err = tx.Exec(ctx, sql, eventType, personID, payload)
+	return err
+}
+
+// OutboxRepository lets one relay at a time process the outbox, so that the events of a person leave in order.
+// The relay holds a lease of the outbox_lease row rather than a transaction, so no connection or row lock is
+// held while it publishes; another relay takes over once the lease expired.
+type OutboxRepository struct {
+	db    *DBRouter
+	lease time.Duration
+}
+
+func NewOutboxRepository(db *pgxpool.Pool, lease time.Duration) entity.OutboxRepository {
+	return &OutboxRepository{db: NewDBRouter(db), lease: lease}
+}
+
+func (r *OutboxRepository) Process(ctx context.Context, limit int, process func(ctx context.Context, events []*entity.Event) map[int]error) (int, error) {
+	sql := `UPDATE outbox_lease
+			SET holder = gen_random_uuid(), expires_at = now() + make_interval(secs => $1)
+			WHERE expires_at IS NULL OR expires_at < now()
+			RETURNING holder::text;`
+	var holder string
+	start := time.Now()
+	err := r.db.Primary().QueryRow(ctx, sql, r.lease.Seconds()).Scan(&holder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_, err := r.db.Primary().Exec(ctx, `UPDATE outbox_lease SET expires_at = NULL WHERE holder = $1::uuid;`, holder)
+		if err != nil {
+			logger.FromContext(ctx).WithField("Error", err).Error("release outbox lease")
+		}
+	}()
+	events, err := pendingEvents(ctx, r.db.Primary(), limit)
+	if err != nil {
+		return 0, err
+	}
+	// the events must be processed before the lease ends and another relay may pass them again
+	processCtx, cancel := context.WithDeadline(ctx, start.Add(r.lease))
+	results := process(processCtx, events)
+	cancel()
+	return len(events), r.db.InTx(ctx, func(tx pgx.Tx) error {
+		for id, processErr := range results {
+			switch {
+			case processErr == nil:
+				_, err = tx.Exec(ctx, `UPDATE outbox SET published_at = now(), attempts = attempts + 1 WHERE id = $1;`, id)
+			case errors.Is(processErr, entity.ErrEventFailed):
+				_, err = tx.Exec(ctx, `UPDATE outbox SET failed_at = now(), attempts = attempts + 1, last_error = $2 WHERE id = $1;`, id, processErr.Error())
+			default:
+				_, err = tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1;`, id, processErr.Error())
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func pendingEvents(ctx context.Context, db Querier, limit int) ([]*entity.Event, error) {
+	sql := `SELECT id, type, person_id, payload, created_at, attempts
+			FROM outbox
+			WHERE published_at IS NULL AND failed_at IS NULL
+			ORDER BY id
+			LIMIT $1;`
+	rows, err := db.Query(ctx, sql, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]*entity.Event, 0)
+	for rows.Next() {
+		event := new(entity.Event)
+		var data []byte
+		err = rows.Scan(&event.ID, &event.Type, &event.PersonID, &data, &event.OccurredAt, &event.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		var payload eventPayload
+		err = json.Unmarshal(data, &payload)
+		if err != nil {
+			return nil, err
+		}
+		event.Before, event.After = payload.Before, payload.After
+		events = append(events, event)
+	}
+	logger.FromContext(ctx).WithField("Rows", len(events)).Debug("select outbox events")
+	return events, rows.Err()
+}
+
+func (r *OutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	sql := `DELETE FROM outbox
+			WHERE published_at < $1;`
+	result, err := r.db.Primary().Exec(ctx, sql, before)
+	return int(result.RowsAffected()), err
+}
@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func truncateOutbox(ctx context.Context, db *pgxpool.Pool) {
+	sql := `TRUNCATE outbox RESTART IDENTITY;`
+	db.Exec(ctx, sql)
+}
+
+func TestOutboxRepository(t *testing.T) {
+	ctx := context.Background()
+	dbPoll := GetTestDb()
+	defer func() {
+		truncate(ctx, dbPoll)
+		truncateOutbox(ctx, dbPoll)
+		dbPoll.Close()
+	}()
+	persons := repository.NewRoutedPersonRepository(repository.NewDBRouter(dbPoll), true)
+	rep := repository.NewOutboxRepository(dbPoll, time.Minute)
+	person, err := persons.Create(ctx, &entity.Person{Email: "outbox@test.ru", Phone: "1234", FirstName: "outbox"})
+	assert.NoError(t, err)
+	_, err = persons.Update(ctx, person.ID, &entity.Person{Email: "outbox@test.ru", Phone: "5678", FirstName: "outbox"})
+	assert.NoError(t, err)
+	assert.NoError(t, persons.Delete(ctx, person.ID))
+
+	var events []*entity.Event
+	n, err := rep.Process(ctx, 10, func(_ context.Context, batch []*entity.Event) map[int]error {
+		events = batch
+		// another relay gets nothing while the lease is held
+		other, err := rep.Process(ctx, 10, func(context.Context, []*entity.Event) map[int]error {
+			t.Error("processed during the lease of another relay")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, other)
+		results := map[int]error{}
+		for i, event := range batch {
+			if i < 2 {
+				results[event.ID] = nil
+			} else {
+				results[event.ID] = errors.New("receiver down")
+			}
+		}
+		return results
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, entity.PersonCreated, events[0].Type)
+		assert.Nil(t, events[0].Before)
+		assert.Equal(t, "1234", events[0].After.Phone)
+		assert.Equal(t, entity.PersonUpdated, events[1].Type)
+		assert.Equal(t, "1234", events[1].Before.Phone)
+		assert.Equal(t, "5678", events[1].After.Phone)
+		assert.Equal(t, entity.PersonDeleted, events[2].Type)
+		assert.Equal(t, person.ID, events[2].PersonID)
+		assert.Nil(t, events[2].After)
+	}
+
+	// the failed event is passed again
+	n, err = rep.Process(ctx, 10, func(_ context.Context, batch []*entity.Event) map[int]error {
+		events = batch
+		return map[int]error{batch[0].ID: nil}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, entity.PersonDeleted, events[0].Type)
+	}
+
+	deleted, err := rep.DeletePublished(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+}
@@ -4,46 +4,92 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
 	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
 	"time"
 )
 
 type PersonRepository struct {
-	db *pgxpool.Pool
+	db *DBRouter
+	// outbox records the changes in the outbox, only when a relay publishes and deletes them
+	outbox bool
 }
 
 func NewPersonRepository(db *pgxpool.Pool) entity.PersonRepository {
-	return &PersonRepository{db: db}
+	return &PersonRepository{db: NewDBRouter(db)}
 }
 
-func (r *PersonRepository) getPersons(ctx context.Context, query string, args ...interface{}) ([]*entity.Person, error) {
+// NewRoutedPersonRepository reads from the replicas of router, see DBRouter, and records the changes
+// in the outbox when outbox is set.
+func NewRoutedPersonRepository(router *DBRouter, outbox bool) entity.PersonRepository {
+	return &PersonRepository{db: router, outbox: outbox}
+}
+
+var personColumns = map[string]func(p *entity.Person) interface{}{
+	"id":         func(p *entity.Person) interface{} { return &p.ID },
+	"email":      func(p *entity.Person) interface{} { return &p.Email },
+	"phone":      func(p *entity.Person) interface{} { return &p.Phone },
+	"first_name": func(p *entity.Person) interface{} { return &p.FirstName },
+	"updated_at": func(p *entity.Person) interface{} { return &p.UpdatedAt },
+}
+
+// columns returns the columns to select for the requested fields: "id" and "updated_at" first, then the
+// known fields in the requested order, or every column when no field is requested.
+func columns(fields []string) []string {
+	if len(fields) == 0 {
+		fields = entity.PersonFields
+	}
+	result := []string{"id", "updated_at"}
+	for _, field := range fields {
+		_, ok := personColumns[field]
+		if ok && !contains(result, field) {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func scanTargets(p *entity.Person, columns []string) []interface{} {
+	targets := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		targets = append(targets, personColumns[column](p))
+	}
+	return targets
+}
+
+func (r *PersonRepository) getPersons(ctx context.Context, query string, fields []string, args ...interface{}) ([]*entity.Person, error) {
 	persons := make([]*entity.Person, 0)
-	rows, err := r.db.Query(ctx, query, args...)
+	selected := columns(fields)
+	rows, err := r.db.Reader(ctx).Query(ctx, fmt.Sprintf(query, strings.Join(selected, ", ")), args...)
 	defer rows.Close()
 	for rows.Next() {
 		p := new(entity.Person)
-		err = rows.Scan(
-			&p.ID,
-			&p.Email,
-			&p.Phone,
-			&p.FirstName,
-		)
+		err = rows.Scan(scanTargets(p, selected)...)
 		persons = append(persons, p)
 	}
+	logger.FromContext(ctx).WithField("Rows", len(persons)).Debug("select persons")
 	return persons, err
 }
 
-func (r *PersonRepository) getOnePerson(ctx context.Context, query string, args ...interface{}) (*entity.Person, error) {
+func (r *PersonRepository) getOnePerson(ctx context.Context, query string, fields []string, args ...interface{}) (*entity.Person, error) {
 	person := new(entity.Person)
-	err := r.db.QueryRow(ctx, query, args...).
-		Scan(&person.ID,
-			&person.Email,
-			&person.Phone,
-			&person.FirstName,
-		)
+	selected := columns(fields)
+	err := r.db.Reader(ctx).QueryRow(ctx, fmt.Sprintf(query, strings.Join(selected, ", ")), args...).
+		Scan(scanTargets(person, selected)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		err, person = nil, nil
 	}
@@ -54,92 +100,152 @@ func (r *PersonRepository) count(ctx context.Context, query, value string) (int,
 	var count int
 	var err error
 	if value == "" {
-		err = r.db.QueryRow(ctx, query).Scan(&count)
+		err = r.db.Reader(ctx).QueryRow(ctx, query).Scan(&count)
 	} else {
-		err = r.db.QueryRow(ctx, query, value).Scan(&count)
+		err = r.db.Reader(ctx).QueryRow(ctx, query, value).Scan(&count)
 	}
 	return count, err
 }
 
-func (r *PersonRepository) GetAll(ctx context.Context, limit, offset int) ([]*entity.Person, error) {
-	sql := `SELECT id, email, phone, first_name
+// estimate returns the planner's row estimate for query.
+func (r *PersonRepository) estimate(ctx context.Context, query string, args ...interface{}) (int, error) {
+	var data string
+	err := r.db.Reader(ctx).QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+query, args...).Scan(&data)
+	if err != nil {
+		return 0, err
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	err = json.Unmarshal([]byte(data), &plans)
+	if err != nil || len(plans) == 0 {
+		return 0, err
+	}
+	logger.FromContext(ctx).WithField("Rows", plans[0].Plan.Rows).Debug("planner estimate")
+	return int(plans[0].Plan.Rows), nil
+}
+
+func (r *PersonRepository) GetAll(ctx context.Context, fields []string, limit, offset int) ([]*entity.Person, error) {
+	sql := `SELECT %s
 			FROM persons
 			ORDER BY id
 			LIMIT $1
 			OFFSET $2;`
-	return r.getPersons(ctx, sql, limit, offset)
+	return r.getPersons(ctx, sql, fields, limit, offset)
 }
 
-func (r *PersonRepository) GetAllByEmail(ctx context.Context, email string, limit, offset int) ([]*entity.Person, error) {
-	sql := `SELECT id, email, phone, first_name
+func (r *PersonRepository) GetAllByEmail(ctx context.Context, email string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	sql := `SELECT %s
 			FROM persons
 			WHERE email = $1
 			ORDER BY id
 			LIMIT $2
 			OFFSET $3;`
-	return r.getPersons(ctx, sql, email, limit, offset)
+	return r.getPersons(ctx, sql, fields, email, limit, offset)
 }
 
-func (r *PersonRepository) GetAllByPhone(ctx context.Context, phone string, limit, offset int) ([]*entity.Person, error) {
-	sql := `SELECT id, email, phone, first_name
+func (r *PersonRepository) GetAllByPhone(ctx context.Context, phone string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	sql := `SELECT %s
 			FROM persons
 			WHERE phone = $1
 			ORDER BY id
 			LIMIT $2
 			OFFSET $3;`
-	return r.getPersons(ctx, sql, phone, limit, offset)
+	return r.getPersons(ctx, sql, fields, phone, limit, offset)
 }
 
-func (r *PersonRepository) GetAllByName(ctx context.Context, firstName string, limit, offset int) ([]*entity.Person, error) {
-	sql := `SELECT id, email, phone, first_name
+func (r *PersonRepository) GetAllByName(ctx context.Context, firstName string, fields []string, limit, offset int) ([]*entity.Person, error) {
+	sql := `SELECT %s
 			FROM persons
 			WHERE first_name = $1
 			ORDER BY id
 			LIMIT $2 
 			OFFSET $3;`
-	return r.getPersons(ctx, sql, firstName, limit, offset)
+	return r.getPersons(ctx, sql, fields, firstName, limit, offset)
 }
 
-func (r *PersonRepository) GetByID(ctx context.Context, id int) (*entity.Person, error) {
-	sql := `SELECT id, email, phone, first_name
+func (r *PersonRepository) GetByID(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	sql := `SELECT %s
 			FROM persons
 			WHERE id = $1;`
-	return r.getOnePerson(ctx, sql, id)
+	return r.getOnePerson(ctx, sql, fields, id)
 }
 
 func (r *PersonRepository) GetByEmail(ctx context.Context, email string) (*entity.Person, error) {
-	sql := `SELECT id, email, phone, first_name
+	sql := `SELECT %s
 			FROM persons
 			WHERE email = $1;`
-	return r.getOnePerson(ctx, sql, email)
+	return r.getOnePerson(ctx, sql, nil, email)
 }
 
+// scanPerson reads every column of the person row, selected in the order of columns(nil).
+func scanPerson(row pgx.Row) (*entity.Person, error) {
+	person := new(entity.Person)
+	err := row.Scan(scanTargets(person, columns(nil))...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, serverErr.ErrNotFound
+	}
+	return person, err
+}
+
+func (r *PersonRepository) recordEvent(ctx context.Context, tx pgx.Tx, eventType entity.EventType, personID int, before, after *entity.Person) error {
+	if !r.outbox {
+		return nil
+	}
+	return insertEvent(ctx, tx, eventType, personID, before, after)
+}
+
+// Create, Update and Delete record the change in the outbox within the same transaction.
 func (r *PersonRepository) Create(ctx context.Context, req *entity.Person) (*entity.Person, error) {
-	sql := `INSERT INTO persons (email, phone, first_name, created_at)
-			VALUES ($1,$2,$3,$4)
-			RETURNING id;`
-	err := r.db.QueryRow(ctx, sql, req.Email, req.Phone, req.FirstName, time.Now().Format(time.DateTime)).Scan(&req.ID)
+	sql := `INSERT INTO persons (email, phone, first_name, created_at, updated_at)
+			VALUES ($1,$2,$3,$4,now())
+			RETURNING id, updated_at;`
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, sql, req.Email, req.Phone, req.FirstName, time.Now().Format(time.DateTime)).Scan(&req.ID, &req.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return r.recordEvent(ctx, tx, entity.PersonCreated, req.ID, nil, req)
+	})
 	return req, err
 }
 
 func (r *PersonRepository) Update(ctx context.Context, id int, req *entity.Person) (*entity.Person, error) {
+	selectSQL := fmt.Sprintf(`SELECT %s
+			FROM persons
+			WHERE id = $1
+			FOR UPDATE;`, strings.Join(columns(nil), ", "))
 	sql := `UPDATE persons
-			SET email = $1, phone = $2, first_name = $3, updated_at = $4
-            WHERE id = $5
-            RETURNING id;`
-	err := r.db.QueryRow(ctx, sql, req.Email, req.Phone, req.FirstName, time.Now().Format(time.DateTime), id).Scan(&req.ID)
+			SET email = $1, phone = $2, first_name = $3, updated_at = now()
+            WHERE id = $4
+            RETURNING id, updated_at;`
+	err := r.db.InTx(ctx, func(tx pgx.Tx) error {
+		before, err := scanPerson(tx.QueryRow(ctx, selectSQL, id))
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRow(ctx, sql, req.Email, req.Phone, req.FirstName, id).Scan(&req.ID, &req.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return r.recordEvent(ctx, tx, entity.PersonUpdated, id, before, req)
+	})
 	return req, err
 }
 
 func (r *PersonRepository) Delete(ctx context.Context, id int) error {
-	sql := `DELETE FROM persons
-       		WHERE id = $1`
-
-	result, err := r.db.Exec(ctx, sql, id)
-	if result.RowsAffected() != 1 {
-		err = serverErr.ErrNotFound
-	}
-	return err
+	sql := fmt.Sprintf(`DELETE FROM persons
+       		WHERE id = $1
+       		RETURNING %s;`, strings.Join(columns(nil), ", "))
+	return r.db.InTx(ctx, func(tx pgx.Tx) error {
+		before, err := scanPerson(tx.QueryRow(ctx, sql, id))
+		if err != nil {
+			return err
+		}
+		return r.recordEvent(ctx, tx, entity.PersonDeleted, id, before, nil)
+	})
 }
 
 func (r *PersonRepository) CountAll(ctx context.Context) (int, error) {
@@ -162,6 +268,32 @@ func (r *PersonRepository) CountAllByName(ctx context.Context, name string) (int
 	return r.count(ctx, sql, name)
 }
 
+func (r *PersonRepository) EstimateCountAll(ctx context.Context) (int, error) {
+	sql := `SELECT reltuples::bigint FROM pg_class WHERE oid = 'persons'::regclass;`
+	count, err := r.count(ctx, sql, "")
+	if err == nil && count < 0 {
+		// the table has never been vacuumed or analyzed, ask the planner instead
+		logger.FromContext(ctx).Debug("no statistics for persons, estimating with the planner")
+		return r.estimate(ctx, `SELECT id FROM persons`)
+	}
+	return count, err
+}
+
+func (r *PersonRepository) EstimateCountAllByEmail(ctx context.Context, email string) (int, error) {
+	sql := `SELECT id FROM persons WHERE email = $1`
+	return r.estimate(ctx, sql, email)
+}
+
+func (r *PersonRepository) EstimateCountAllByPhone(ctx context.Context, phone string) (int, error) {
+	sql := `SELECT id FROM persons WHERE phone = $1`
+	return r.estimate(ctx, sql, phone)
+}
+
+func (r *PersonRepository) EstimateCountAllByName(ctx context.Context, name string) (int, error) {
+	sql := `SELECT id FROM persons WHERE first_name = $1`
+	return r.estimate(ctx, sql, name)
+}
+
 func (r *PersonRepository) ParseData(data []byte) (*entity.Person, error) {
 	person := new(entity.Person)
 	err := json.Unmarshal(data, &person)
@@ -55,7 +55,7 @@ func TestPersonRepository_GetAll(t *testing.T) {
 	ListPerson := make([]*entity.Person, 0)
 	ListPerson = append(ListPerson, testPerson1)
 	ListPerson = append(ListPerson, testPerson2)
-	result, err := rep.GetAll(ctx, 10, 0)
+	result, err := rep.GetAll(ctx, nil, 10, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, ListPerson, result)
@@ -73,7 +73,7 @@ func TestPersonRepository_GetAllByEmail(t *testing.T) {
 	rep.Create(ctx, testPerson2)
 	ListPerson := make([]*entity.Person, 0)
 	ListPerson = append(ListPerson, testPerson1)
-	result, err := rep.GetAllByEmail(ctx, testPerson1.Email, 10, 0)
+	result, err := rep.GetAllByEmail(ctx, testPerson1.Email, nil, 10, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, ListPerson, result)
@@ -92,7 +92,7 @@ func TestPersonRepository_GetAllByName(t *testing.T) {
 	ListPerson := make([]*entity.Person, 0)
 	ListPerson = append(ListPerson, testPerson1)
 	ListPerson = append(ListPerson, testPerson3)
-	result, err := rep.GetAllByName(ctx, testPerson1.FirstName, 10, 0)
+	result, err := rep.GetAllByName(ctx, testPerson1.FirstName, nil, 10, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, ListPerson, result)
@@ -112,7 +112,7 @@ func TestPersonRepository_GetAllByPhone(t *testing.T) {
 	ListPerson := make([]*entity.Person, 0)
 	ListPerson = append(ListPerson, testPerson1)
 	ListPerson = append(ListPerson, testPerson3)
-	result, err := rep.GetAllByPhone(ctx, testPerson1.Phone, 10, 0)
+	result, err := rep.GetAllByPhone(ctx, testPerson1.Phone, nil, 10, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, ListPerson, result)
@@ -127,12 +127,30 @@ func TestPersonRepository_GetByID(t *testing.T) {
 	}()
 	rep := repository.NewPersonRepository(dbPoll)
 	rep.Create(ctx, testPerson1)
-	result, err := rep.GetByID(ctx, testPerson1.ID)
+	result, err := rep.GetByID(ctx, testPerson1.ID, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, testPerson1, result)
 }
 
+func TestPersonRepository_GetFields(t *testing.T) {
+	ctx := context.Background()
+	dbPoll := GetTestDb()
+	defer func() {
+		truncate(ctx, dbPoll)
+		dbPoll.Close()
+	}()
+	rep := repository.NewPersonRepository(dbPoll)
+	rep.Create(ctx, testPerson1)
+	sparsePerson := &entity.Person{ID: testPerson1.ID, FirstName: testPerson1.FirstName, UpdatedAt: testPerson1.UpdatedAt}
+	result, err := rep.GetAll(ctx, []string{"first_name", "unknown"}, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.Person{sparsePerson}, result)
+	person, err := rep.GetByID(ctx, testPerson1.ID, []string{"first_name"})
+	assert.NoError(t, err)
+	assert.Equal(t, sparsePerson, person)
+}
+
 func TestPersonRepository_GetByEmail(t *testing.T) {
 	ctx := context.Background()
 	dbPoll := GetTestDb()
@@ -258,6 +276,32 @@ func TestPersonRepository_CountAllByPhone(t *testing.T) {
 	assert.Equal(t, 2, result)
 }
 
+func TestPersonRepository_EstimateCount(t *testing.T) {
+	ctx := context.Background()
+	dbPoll := GetTestDb()
+	defer func() {
+		truncate(ctx, dbPoll)
+		dbPoll.Close()
+	}()
+	rep := repository.NewPersonRepository(dbPoll)
+	rep.Create(ctx, testPerson1)
+	rep.Create(ctx, testPerson2)
+	rep.Create(ctx, testPerson3)
+	dbPoll.Exec(ctx, `ANALYZE persons;`)
+	result, err := rep.EstimateCountAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result)
+	result, err = rep.EstimateCountAllByEmail(ctx, testPerson1.Email)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, result, 1)
+	result, err = rep.EstimateCountAllByPhone(ctx, testPerson1.Phone)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, result, 1)
+	result, err = rep.EstimateCountAllByName(ctx, testPerson1.FirstName)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, result, 1)
+}
+
 func TestPersonRepository_ParseData(t *testing.T) {
 	data, _ := json.Marshal(testPerson1)
 	ctx := context.Background()
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+// deliveryColumns are the columns scanDelivery reads, of webhook_deliveries aliased as d.
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			coalesce(d.response_status, 0), coalesce(d.last_error, ''), d.next_attempt_at, d.delivered_at, d.created_at`
+
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) entity.WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func scanWebhook(row pgx.Row, webhook *entity.Webhook) error {
+	var events []string
+	err := row.Scan(&webhook.ID, &webhook.URL, &events, &webhook.CreatedAt)
+	webhook.Events = toEventTypes(events)
+	return err
+}
+
+func toEventTypes(events []string) []entity.EventType {
+	types := make([]entity.EventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, entity.EventType(event))
+	}
+	return types
+}
+
+func fromEventTypes(types []entity.EventType) []string {
+	events := make([]string, 0, len(types))
+	for _, event := range types {
+		events = append(events, string(event))
+	}
+	return events
+}
+
+func scanDelivery(row pgx.Row, delivery *entity.WebhookDelivery, extra ...interface{}) error {
+	var payload []byte
+	err := row.Scan(append([]interface{}{
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	}, extra...)...)
+	delivery.Payload = payload
+	return err
+}
+
+func (r *WebhookRepository) getDeliveries(ctx context.Context, query string, args ...interface{}) ([]*entity.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := make([]*entity.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := new(entity.WebhookDelivery)
+		err = scanDelivery(rows, delivery)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) GetAll(ctx context.Context) ([]*entity.Webhook, error) {
+	sql := `SELECT id, url, events, created_at
+			FROM webhooks
+			ORDER BY id;`
+	rows, err := r.db.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	webhooks := make([]*entity.Webhook, 0)
+	for rows.Next() {
+		webhook := new(entity.Webhook)
+		err = scanWebhook(rows, webhook)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int) (*entity.Webhook, error) {
+	sql := `SELECT id, url, events, created_at
+			FROM webhooks
+			WHERE id = $1;`
+	webhook := new(entity.Webhook)
+	err := scanWebhook(r.db.QueryRow(ctx, sql, id), webhook)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err, webhook = nil, nil
+	}
+	return webhook, err
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, req *entity.Webhook) (*entity.Webhook, error) {
+	sql := `INSERT INTO webhooks (url, events, secret)
+			VALUES ($1,$2,$3)
+			RETURNING id, created_at;`
+	err := r.db.QueryRow(ctx, sql, req.URL, fromEventTypes(req.Events), req.Secret).Scan(&req.ID, &req.CreatedAt)
+	return req, err
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, id int, req *entity.Webhook) (*entity.Webhook, error) {
+	sql := `UPDATE webhooks
+			SET url = $1, events = $2, secret = coalesce(nullif($3, ''), secret)
+			WHERE id = $4
+			RETURNING id, created_at;`
+	err := r.db.QueryRow(ctx, sql, req.URL, fromEventTypes(req.Events), req.Secret, id).Scan(&req.ID, &req.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, serverErr.ErrNotFound
+	}
+	return req, err
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int) error {
+	sql := `DELETE FROM webhooks
+			WHERE id = $1;`
+	result, err := r.db.Exec(ctx, sql, id)
+	if err == nil && result.RowsAffected() != 1 {
+		err = serverErr.ErrNotFound
+	}
+	return err
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, event *entity.Event) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	sql := `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+			SELECT id, $1, $2, $3
+			FROM webhooks
+			WHERE events = '{}' OR $2 = ANY(events)
+			ON CONFLICT (webhook_id, event_id) DO NOTHING;`
+	result, err := r.db.Exec(ctx, sql, event.ID, string(event.Type), payload)
+	if err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).WithField("Rows", result.RowsAffected()).Debug("enqueue webhook deliveries")
+	return int(result.RowsAffected()), nil
+}
+
+func (r *WebhookRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	sql := `WITH claimed AS (
+				UPDATE webhook_deliveries
+				SET next_attempt_at = now() + make_interval(secs => $2)
+				WHERE id IN (
+					SELECT id
+					FROM webhook_deliveries
+					WHERE status = 'pending' AND next_attempt_at <= now()
+					ORDER BY next_attempt_at, id
+					LIMIT $1
+					FOR UPDATE SKIP LOCKED)
+				RETURNING *)
+			SELECT ` + deliveryColumns + `, w.url, w.secret
+			FROM claimed d
+			JOIN webhooks w ON w.id = d.webhook_id
+			ORDER BY d.id;`
+	rows, err := r.db.Query(ctx, sql, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := make([]*entity.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := new(entity.WebhookDelivery)
+		err = scanDelivery(rows, delivery, &delivery.URL, &delivery.Secret)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	sql := `UPDATE webhook_deliveries
+			SET status = $2, attempts = $3, response_status = nullif($4, 0), last_error = nullif($5, ''),
+			    next_attempt_at = $6, delivered_at = $7
+			WHERE id = $1;`
+	_, err := r.db.Exec(ctx, sql, delivery.ID, string(delivery.Status), delivery.Attempts, delivery.ResponseStatus,
+		delivery.LastError, delivery.NextAttemptAt, delivery.DeliveredAt)
+	return err
+}
+
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookID int, limit int) ([]*entity.WebhookDelivery, error) {
+	sql := `SELECT ` + deliveryColumns + `
+			FROM webhook_deliveries d
+			WHERE d.webhook_id = $1
+			ORDER BY d.id DESC
+			LIMIT $2;`
+	return r.getDeliveries(ctx, sql, webhookID, limit)
+}
+
+func (r *WebhookRepository) Redeliver(ctx context.Context, webhookID, id int) (*entity.WebhookDelivery, error) {
+	sql := `UPDATE webhook_deliveries d
+			SET status = 'pending', attempts = 0, next_attempt_at = now()
+			WHERE d.webhook_id = $1 AND d.id = $2
+			RETURNING ` + deliveryColumns + `;`
+	delivery := new(entity.WebhookDelivery)
+	err := scanDelivery(r.db.QueryRow(ctx, sql, webhookID, id), delivery)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, serverErr.ErrNotFound
+	}
+	return delivery, err
+}
@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	serverErr "github.com/RomanUtolin/RESTful-CRUD/internall/errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func truncateWebhooks(ctx context.Context, db *pgxpool.Pool) {
+	sql := `TRUNCATE webhooks, webhook_deliveries RESTART IDENTITY;`
+	db.Exec(ctx, sql)
+}
+
+func TestWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	dbPoll := GetTestDb()
+	defer func() {
+		truncateWebhooks(ctx, dbPoll)
+		dbPoll.Close()
+	}()
+	rep := repository.NewWebhookRepository(dbPoll)
+	all, err := rep.Create(ctx, &entity.Webhook{URL: "https://all.test/hooks", Secret: "whsec_all"})
+	assert.NoError(t, err)
+	deletes, err := rep.Create(ctx, &entity.Webhook{URL: "https://deletes.test/hooks", Events: []entity.EventType{entity.PersonDeleted}, Secret: "whsec_deletes"})
+	assert.NoError(t, err)
+
+	webhook, err := rep.GetByID(ctx, deletes.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, webhook) {
+		assert.Equal(t, []entity.EventType{entity.PersonDeleted}, webhook.Events)
+		assert.Empty(t, webhook.Secret)
+	}
+
+	created := &entity.Event{ID: 1, Type: entity.PersonCreated, PersonID: 1, After: &entity.Person{ID: 1}}
+	n, err := rep.Enqueue(ctx, created)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	// the relay may publish an event again
+	n, err = rep.Enqueue(ctx, created)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	n, err = rep.Enqueue(ctx, &entity.Event{ID: 2, Type: entity.PersonDeleted, PersonID: 1, Before: &entity.Person{ID: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	claimed, err := rep.Claim(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 3)
+	again, err := rep.Claim(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+	if len(claimed) == 3 {
+		assert.Equal(t, all.ID, claimed[0].WebhookID)
+		assert.Equal(t, "https://all.test/hooks", claimed[0].URL)
+		assert.Equal(t, "whsec_all", claimed[0].Secret)
+
+		dead := claimed[0]
+		dead.Status, dead.Attempts, dead.ResponseStatus, dead.LastError, dead.NextAttemptAt = entity.DeliveryDead, 8, 500, "unexpected status 500", nil
+		assert.NoError(t, rep.RecordAttempt(ctx, dead))
+		deliveries, err := rep.GetDeliveries(ctx, all.ID, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, deliveries, 2) {
+			assert.Equal(t, dead.ID, deliveries[1].ID)
+			assert.Equal(t, entity.DeliveryDead, deliveries[1].Status)
+			assert.Equal(t, 500, deliveries[1].ResponseStatus)
+		}
+
+		redelivered, err := rep.Redeliver(ctx, all.ID, dead.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, redelivered) {
+			assert.Equal(t, entity.DeliveryPending, redelivered.Status)
+			assert.Equal(t, 0, redelivered.Attempts)
+		}
+		claimed, err = rep.Claim(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, claimed, 1)
+	}
+	_, err = rep.Redeliver(ctx, deletes.ID, 999)
+	assert.Equal(t, serverErr.ErrNotFound, err)
+
+	assert.NoError(t, rep.Delete(ctx, deletes.ID))
+	assert.Equal(t, serverErr.ErrNotFound, rep.Delete(ctx, deletes.ID))
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/health"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"time"
+)
+
+type Server struct {
+	Echo   *echo.Echo
+	Health *health.Health
+	// DrainDelay is how long the service keeps serving while reporting not ready, so that load balancers
+	// stop sending new requests before the listener closes.
+	DrainDelay time.Duration
+	// ShutdownTimeout bounds the wait for in-flight requests, whose contexts are canceled after it.
+	ShutdownTimeout time.Duration
+}
+
+// Run serves on address until ctx is done, then drains and shuts down gracefully.
+// It returns once every request has finished or the shutdown timeout passed.
+func (s *Server) Run(ctx context.Context, address string) error {
+	base, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	s.Echo.Server.BaseContext = func(net.Listener) context.Context { return base }
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Echo.Start(address)
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	logrus.Info("shutting down, draining")
+	s.Health.Drain()
+	time.Sleep(s.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+	err := s.Echo.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logrus.Warning("shutdown timeout passed, canceling in-flight requests")
+		cancelRequests()
+		err = s.Echo.Close()
+	}
+	if serveErr := <-serveErr; serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+	logrus.Info("server stopped")
+	return err
+}
@@ -0,0 +1,134 @@
+package server_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/health"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/reload"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/server"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type testServer struct {
+	app     *server.Server
+	started chan struct{}
+	done    chan error
+	stop    context.CancelFunc
+}
+
+func startServer(t *testing.T, shutdownTimeout time.Duration, handler echo.HandlerFunc) *testServer {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	s := &testServer{
+		app: &server.Server{
+			Echo:            e,
+			Health:          health.NewHealth(reload.NewValue(time.Second)),
+			DrainDelay:      100 * time.Millisecond,
+			ShutdownTimeout: shutdownTimeout,
+		},
+		started: make(chan struct{}, 1),
+		done:    make(chan error, 1),
+	}
+	e.GET("/slow", func(c echo.Context) error {
+		s.started <- struct{}{}
+		return handler(c)
+	})
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop = stop
+	go func() {
+		s.done <- s.app.Run(ctx, "127.0.0.1:0")
+	}()
+	require.Eventually(t, func() bool { return e.ListenerAddr() != nil }, time.Second, 10*time.Millisecond)
+	return s
+}
+
+func (s *testServer) url(path string) string {
+	return "http://" + s.app.Echo.ListenerAddr().String() + path
+}
+
+func TestServer_InFlightRequestsComplete(t *testing.T) {
+	s := startServer(t, 5*time.Second, func(c echo.Context) error {
+		time.Sleep(500 * time.Millisecond)
+		return c.String(http.StatusOK, "done")
+	})
+	url := s.url("/slow")
+
+	type result struct {
+		code int
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- result{code: resp.StatusCode, body: string(body)}
+	}()
+	<-s.started
+	s.stop()
+
+	require.Eventually(t, func() bool {
+		return s.app.Health.Readiness(context.Background()).Draining
+	}, time.Second, 10*time.Millisecond)
+	r := <-response
+	require.NoError(t, r.err)
+	assert.Equal(t, http.StatusOK, r.code)
+	assert.Equal(t, "done", r.body)
+	assert.NoError(t, <-s.done)
+
+	_, err := http.Get(url)
+	assert.Error(t, err, "the listener is closed after shutdown")
+}
+
+func TestServer_ShutdownTimeoutCancelsRequests(t *testing.T) {
+	canceled := make(chan struct{})
+	s := startServer(t, 200*time.Millisecond, func(c echo.Context) error {
+		select {
+		case <-c.Request().Context().Done():
+			close(canceled)
+			return c.Request().Context().Err()
+		case <-time.After(10 * time.Second):
+			return c.NoContent(http.StatusOK)
+		}
+	})
+	go func() {
+		resp, err := http.Get(s.url("/slow"))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-s.started
+	start := time.Now()
+	s.stop()
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight request context was not canceled")
+	}
+	<-s.done
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestServer_AddressInUse(t *testing.T) {
+	s := startServer(t, time.Second, func(c echo.Context) error { return nil })
+	defer s.stop()
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	app := &server.Server{Echo: e, Health: health.NewHealth(reload.NewValue(time.Second)), ShutdownTimeout: time.Second}
+
+	err := app.Run(context.Background(), s.app.Echo.ListenerAddr().String())
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		wait string
+	}{
+		{
+			name: "placeholders kept",
+			sql: `SELECT id, email
+			FROM persons
+			WHERE email = $1
+			LIMIT $2;`,
+			wait: "SELECT id, email FROM persons WHERE email = $1 LIMIT $2;",
+		},
+		{
+			name: "literals replaced",
+			sql:  `SELECT id FROM persons WHERE email = 'o''brien@test.ru' AND id > 42 LIMIT 10`,
+			wait: "SELECT id FROM persons WHERE email = ? AND id > ? LIMIT ?",
+		},
+		{
+			name: "identifiers with digits kept",
+			sql:  `SELECT reltuples::bigint FROM pg_class WHERE oid = 'persons'::regclass`,
+			wait: "SELECT reltuples::bigint FROM pg_class WHERE oid = ?::regclass",
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.wait, tracing.SanitizeSQL(test.sql), test.name)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = tracing.Setup(context.Background(), tracing.Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
+
+func TestQueryTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	tracer := tracing.NewQueryTracer()
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "DELETE FROM persons WHERE id = $1",
+		Args: []interface{}{"secret"},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("DELETE 1")})
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "db.delete", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.String("db.statement", "DELETE FROM persons WHERE id = $1"))
+	assert.Contains(t, spans[0].Attributes, attribute.Int64("db.rows_affected", 1))
+	for _, kv := range spans[0].Attributes {
+		assert.NotEqual(t, "secret", kv.Value.Emit())
+	}
+	assert.Equal(t, "db.select", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+var ErrForbiddenAddress = errors.New("not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range, private to the provider networks.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// CheckIP rejects the addresses of this host and of internal networks, so that webhooks cannot reach
+// services that are not exposed to the internet.
+func CheckIP(ip net.IP) error {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%s: %w", ip, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// CheckHost rejects host when it is, or resolves to, an address CheckIP rejects. Hosts that do not resolve
+// pass, the Dispatcher checks the address it connects to anyway.
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckIP(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		err = CheckIP(addr.IP)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialControl checks the resolved address right before connecting, a check of the URL only could be
+// bypassed by a DNS answer that changes after it.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	return CheckIP(net.ParseIP(host))
+}
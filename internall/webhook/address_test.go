@@ -0,0 +1,39 @@
+package webhook_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/webhook"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestCheckIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		ip        string
+		waitError bool
+	}{
+		{name: "public v4", ip: "203.0.113.10"},
+		{name: "public v6", ip: "2001:db8::1"},
+		{name: "loopback", ip: "127.0.0.1", waitError: true},
+		{name: "loopback v6", ip: "::1", waitError: true},
+		{name: "private", ip: "10.1.2.3", waitError: true},
+		{name: "private v6", ip: "fd12::1", waitError: true},
+		{name: "link local", ip: "169.254.169.254", waitError: true},
+		{name: "link local v6", ip: "fe80::1", waitError: true},
+		{name: "unspecified", ip: "0.0.0.0", waitError: true},
+		{name: "multicast", ip: "224.0.0.1", waitError: true},
+		{name: "shared address space", ip: "100.64.0.1", waitError: true},
+		{name: "mapped loopback", ip: "::ffff:127.0.0.1", waitError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := webhook.CheckIP(net.ParseIP(test.ip))
+			if test.waitError {
+				assert.ErrorIs(t, err, webhook.ErrForbiddenAddress)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/logger"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const userAgent = "person-api-webhooks"
+
+// Config tunes the Dispatcher. A delivery is attempted MaxAttempts times at most, waiting Backoff after
+// the first failure and twice as long after every further one, MaxBackoff at most.
+type Config struct {
+	BatchSize   int
+	Interval    time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher sends the pending deliveries to their webhooks. Any 2xx answer delivers the event, other
+// answers, redirects included, and errors are retried until the delivery is dead. Only public addresses
+// are connected to, and only the status of an answer is recorded, so webhooks cannot read internal services.
+type Dispatcher struct {
+	Rep    entity.WebhookRepository
+	Client *http.Client
+	Config Config
+}
+
+func NewDispatcher(rep entity.WebhookRepository, cfg Config) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, Control: dialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// through a proxy the address checked would be the proxy's
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &Dispatcher{Rep: rep, Client: client, Config: cfg}
+}
+
+// DispatchOnce sends one batch of due deliveries concurrently and returns their number.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	// a claimed delivery must not be claimed again while it is being sent
+	deliveries, err := d.Rep.Claim(ctx, d.Config.BatchSize, 2*d.Config.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *entity.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *entity.WebhookDelivery) {
+	delivery.ResponseStatus = 0
+	err := d.send(ctx, delivery)
+	delivery.Attempts++
+	now := time.Now()
+	entry := logger.FromContext(ctx).WithFields(logrus.Fields{
+		"Delivery": delivery.ID,
+		"Webhook":  delivery.WebhookID,
+		"Attempts": delivery.Attempts,
+	})
+	switch {
+	case err == nil:
+		delivery.Status = entity.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		entry.Debug("webhook delivered")
+	case delivery.Attempts >= d.Config.MaxAttempts:
+		delivery.Status = entity.DeliveryDead
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = nil
+		entry.WithField("Error", err).Error("webhook delivery dead")
+	default:
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.Status = entity.DeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = &next
+		entry.WithField("Error", err).Warning("webhook delivery failed")
+	}
+	err = d.Rep.RecordAttempt(ctx, delivery)
+	if err != nil {
+		entry.WithField("Error", err).Error("record webhook attempt")
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderWebhookID, strconv.Itoa(delivery.EventID))
+	req.Header.Set(HeaderWebhookEvent, string(delivery.EventType))
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderWebhookSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	delivery.ResponseStatus = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// backoff is the wait after the attempts-th failed attempt.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.Config.Backoff
+	for i := 1; i < attempts && wait < d.Config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.Config.MaxBackoff {
+		wait = d.Config.MaxBackoff
+	}
+	return wait
+}
+
+// Run dispatches the due deliveries every Interval, batch after batch while they are full, until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for ctx.Err() == nil {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil {
+				logger.FromContext(ctx).WithField("Error", err).Error("webhook dispatch")
+			}
+			if err != nil || n < d.Config.BatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/webhook"
+	"github.com/RomanUtolin/RESTful-CRUD/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+var testConfig = webhook.Config{
+	BatchSize:   10,
+	Interval:    time.Second,
+	Timeout:     time.Second,
+	MaxAttempts: 3,
+	Backoff:     10 * time.Second,
+	MaxBackoff:  15 * time.Second,
+}
+
+func testPayload(t *testing.T) []byte {
+	payload, err := json.Marshal(&entity.Event{ID: 7, Type: entity.PersonCreated, PersonID: 1, After: &entity.Person{ID: 1, FirstName: "test"}})
+	require.NoError(t, err)
+	return payload
+}
+
+// verifyingReceiver answers status to requests carrying a valid signature and 401 to the others.
+func verifyingReceiver(t *testing.T, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "7", r.Header.Get(webhook.HeaderWebhookID))
+		assert.Equal(t, string(entity.PersonCreated), r.Header.Get(webhook.HeaderWebhookEvent))
+		if webhook.Verify(testSecret, r.Header, body, time.Minute) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("receiver says hi"))
+	}
+}
+
+func TestDispatcher_DispatchOnce(t *testing.T) {
+	tests := []struct {
+		name               string
+		handler            http.HandlerFunc
+		secret             string
+		closed             bool
+		guarded            bool
+		attempts           int
+		waitStatus         entity.DeliveryStatus
+		waitResponseStatus int
+		waitError          string
+		waitBackoff        time.Duration
+	}{
+		{
+			name:               "delivered",
+			handler:            verifyingReceiver(t, http.StatusNoContent),
+			waitStatus:         entity.DeliverySucceeded,
+			waitResponseStatus: http.StatusNoContent,
+		},
+		{
+			name:               "server error",
+			handler:            verifyingReceiver(t, http.StatusInternalServerError),
+			waitStatus:         entity.DeliveryPending,
+			waitResponseStatus: http.StatusInternalServerError,
+			waitError:          "unexpected status 500",
+			waitBackoff:        10 * time.Second,
+		},
+		{
+			name:               "wrong secret",
+			handler:            verifyingReceiver(t, http.StatusOK),
+			secret:             "whsec_other",
+			waitStatus:         entity.DeliveryPending,
+			waitResponseStatus: http.StatusUnauthorized,
+			waitError:          "unexpected status 401",
+			waitBackoff:        10 * time.Second,
+		},
+		{
+			name: "redirect",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			},
+			attempts:           1,
+			waitStatus:         entity.DeliveryPending,
+			waitResponseStatus: http.StatusFound,
+			waitError:          "unexpected status 302",
+			waitBackoff:        15 * time.Second,
+		},
+		{
+			name:        "unreachable",
+			handler:     verifyingReceiver(t, http.StatusOK),
+			closed:      true,
+			waitStatus:  entity.DeliveryPending,
+			waitError:   "connection refused",
+			waitBackoff: 10 * time.Second,
+		},
+		{
+			name:        "internal address",
+			handler:     verifyingReceiver(t, http.StatusOK),
+			guarded:     true,
+			waitStatus:  entity.DeliveryPending,
+			waitError:   "not a public address",
+			waitBackoff: 10 * time.Second,
+		},
+		{
+			name:               "last attempt",
+			handler:            verifyingReceiver(t, http.StatusBadGateway),
+			attempts:           2,
+			waitStatus:         entity.DeliveryDead,
+			waitResponseStatus: http.StatusBadGateway,
+			waitError:          "unexpected status 502",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			receiver := httptest.NewServer(test.handler)
+			defer receiver.Close()
+			if test.closed {
+				receiver.Close()
+			}
+			secret := test.secret
+			if secret == "" {
+				secret = testSecret
+			}
+			delivery := &entity.WebhookDelivery{
+				ID:        3,
+				WebhookID: 1,
+				EventID:   7,
+				EventType: entity.PersonCreated,
+				Payload:   testPayload(t),
+				Status:    entity.DeliveryPending,
+				Attempts:  test.attempts,
+				URL:       receiver.URL,
+				Secret:    secret,
+			}
+			mockRep := new(mocks.WebhookRepository)
+			mockRep.On("Claim", mock.Anything, 10, 2*time.Second).Return([]*entity.WebhookDelivery{delivery}, nil)
+			var recorded *entity.WebhookDelivery
+			mockRep.On("RecordAttempt", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				recorded = args.Get(1).(*entity.WebhookDelivery)
+			})
+
+			dispatcher := webhook.NewDispatcher(mockRep, testConfig)
+			if !test.guarded {
+				// the receiver listens on loopback, which the dispatcher refuses
+				dispatcher.Client.Transport = receiver.Client().Transport
+			}
+			start := time.Now()
+			n, err := dispatcher.DispatchOnce(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			require.NotNil(t, recorded)
+			assert.Equal(t, test.waitStatus, recorded.Status)
+			assert.Equal(t, test.attempts+1, recorded.Attempts)
+			assert.Equal(t, test.waitResponseStatus, recorded.ResponseStatus)
+			if test.waitError == "" {
+				assert.Empty(t, recorded.LastError)
+				assert.NotNil(t, recorded.DeliveredAt)
+			} else {
+				assert.Contains(t, recorded.LastError, test.waitError)
+				assert.NotContains(t, recorded.LastError, "receiver says hi")
+				assert.Nil(t, recorded.DeliveredAt)
+			}
+			if test.waitBackoff > 0 {
+				require.NotNil(t, recorded.NextAttemptAt)
+				assert.WithinDuration(t, start.Add(test.waitBackoff), *recorded.NextAttemptAt, time.Second)
+			} else {
+				assert.Nil(t, recorded.NextAttemptAt)
+			}
+			mockRep.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	event := &entity.Event{ID: 7, Type: entity.PersonDeleted, PersonID: 1}
+	mockRep := new(mocks.WebhookRepository)
+	mockRep.On("Enqueue", mock.Anything, event).Return(2, nil)
+	assert.NoError(t, webhook.NewPublisher(mockRep).Publish(context.Background(), event))
+	mockRep.AssertExpectations(t)
+}
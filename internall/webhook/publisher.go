@@ -0,0 +1,21 @@
+package webhook
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+)
+
+// Publisher is the outbox.Publisher of the webhooks: it queues a delivery of the event to every webhook
+// subscribed to it, the Dispatcher sends them.
+type Publisher struct {
+	Rep entity.WebhookRepository
+}
+
+func NewPublisher(rep entity.WebhookRepository) *Publisher {
+	return &Publisher{Rep: rep}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event *entity.Event) error {
+	_, err := p.Rep.Enqueue(ctx, event)
+	return err
+}
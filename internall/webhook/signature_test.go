@@ -0,0 +1,37 @@
+package webhook_test
+
+import (
+	"github.com/RomanUtolin/RESTful-CRUD/internall/webhook"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":7}`)
+	now := time.Now().Unix()
+	header := func(timestamp int64, signature string) http.Header {
+		h := http.Header{}
+		h.Set(webhook.HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+		h.Set(webhook.HeaderWebhookSignature, signature)
+		return h
+	}
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		waitErr error
+	}{
+		{name: "valid", header: header(now, webhook.Sign(testSecret, now, body)), body: body},
+		{name: "tampered body", header: header(now, webhook.Sign(testSecret, now, body)), body: []byte(`{"id":8}`), waitErr: webhook.ErrInvalidSignature},
+		{name: "other secret", header: header(now, webhook.Sign("whsec_other", now, body)), body: body, waitErr: webhook.ErrInvalidSignature},
+		{name: "replayed", header: header(now-600, webhook.Sign(testSecret, now-600, body)), body: body, waitErr: webhook.ErrInvalidSignature},
+		{name: "no timestamp", header: http.Header{}, body: body, waitErr: webhook.ErrInvalidSignature},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.waitErr, webhook.Verify(testSecret, test.header, test.body, 5*time.Minute), test.name)
+	}
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", webhook.Sign(testSecret, now, body))
+}
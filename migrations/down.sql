@@ -1 +1,7 @@
-DROP TABLE persons;
\ No newline at end of file
+DROP TABLE schema_migrations;
+DROP TABLE outbox_lease;
+DROP TABLE outbox;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+DROP TABLE api_keys;
+DROP TABLE persons;
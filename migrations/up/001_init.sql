@@ -0,0 +1,26 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS persons(
+                      id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                      email varchar(255) UNIQUE NOT NULL,
+                      phone varchar(255) NOT NULL,
+                      first_name varchar(255) NOT NULL,
+                      created_at timestamp,
+                      updated_at timestamp
+);
+CREATE TABLE IF NOT EXISTS api_keys(
+                      id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                      name varchar(255) NOT NULL,
+                      prefix varchar(32) NOT NULL,
+                      hash char(64) UNIQUE NOT NULL,
+                      scopes text[] NOT NULL DEFAULT '{}',
+                      expires_at timestamptz,
+                      last_used_at timestamptz,
+                      revoked_at timestamptz,
+                      created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS schema_migrations(
+                      version integer PRIMARY KEY,
+                      applied_at timestamptz NOT NULL DEFAULT now()
+);
+INSERT INTO schema_migrations (version) VALUES (1) ON CONFLICT DO NOTHING;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+UPDATE persons SET updated_at = coalesce(created_at, now()) WHERE updated_at IS NULL;
+ALTER TABLE persons ALTER COLUMN updated_at TYPE timestamptz,
+                    ALTER COLUMN updated_at SET DEFAULT now(),
+                    ALTER COLUMN updated_at SET NOT NULL;
+INSERT INTO schema_migrations (version) VALUES (2) ON CONFLICT DO NOTHING;
+COMMIT;
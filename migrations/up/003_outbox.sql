@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS outbox(
+                      id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                      type varchar(64) NOT NULL,
+                      person_id bigint NOT NULL,
+                      payload jsonb NOT NULL,
+                      created_at timestamptz NOT NULL DEFAULT now(),
+                      published_at timestamptz,
+                      attempts integer NOT NULL DEFAULT 0,
+                      last_error text
+);
+CREATE INDEX IF NOT EXISTS outbox_unpublished ON outbox (id) WHERE published_at IS NULL;
+INSERT INTO schema_migrations (version) VALUES (3) ON CONFLICT DO NOTHING;
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS webhooks(
+                      id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                      url text NOT NULL,
+                      events text[] NOT NULL DEFAULT '{}',
+                      secret text NOT NULL,
+                      created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+                      id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                      webhook_id bigint NOT NULL REFERENCES webhooks ON DELETE CASCADE,
+                      event_id bigint NOT NULL,
+                      event_type varchar(64) NOT NULL,
+                      payload jsonb NOT NULL,
+                      status varchar(16) NOT NULL DEFAULT 'pending',
+                      attempts integer NOT NULL DEFAULT 0,
+                      response_status integer,
+                      last_error text,
+                      next_attempt_at timestamptz DEFAULT now(),
+                      delivered_at timestamptz,
+                      created_at timestamptz NOT NULL DEFAULT now(),
+                      UNIQUE (webhook_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+INSERT INTO schema_migrations (version) VALUES (4) ON CONFLICT DO NOTHING;
+COMMIT;
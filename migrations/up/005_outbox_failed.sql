@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS failed_at timestamptz;
+DROP INDEX IF EXISTS outbox_unpublished;
+CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (id) WHERE published_at IS NULL AND failed_at IS NULL;
+INSERT INTO schema_migrations (version) VALUES (5) ON CONFLICT DO NOTHING;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS outbox_lease(
+                      id boolean PRIMARY KEY DEFAULT true CHECK (id),
+                      holder uuid,
+                      expires_at timestamptz
+);
+INSERT INTO outbox_lease DEFAULT VALUES ON CONFLICT DO NOTHING;
+INSERT INTO schema_migrations (version) VALUES (6) ON CONFLICT DO NOTHING;
+COMMIT;
@@ -0,0 +1,70 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+
+	auth "github.com/RomanUtolin/RESTful-CRUD/internall/auth"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Authenticator is an autogenerated mock type for the Authenticator type
+type Authenticator struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: req
+func (_m *Authenticator) Authenticate(req *http.Request) (*auth.Principal, error) {
+	ret := _m.Called(req)
+
+	var r0 *auth.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*auth.Principal, error)); ok {
+		return rf(req)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *auth.Principal); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auth.Principal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Challenge provides a mock function with given fields: err
+func (_m *Authenticator) Challenge(err error) string {
+	ret := _m.Called(err)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(error) string); ok {
+		r0 = rf(err)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// NewAuthenticator creates a new instance of Authenticator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuthenticator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Authenticator {
+	mock := &Authenticator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,66 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// CacheBackend is an autogenerated mock type for the CacheBackend type
+type CacheBackend struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, key
+func (_m *CacheBackend) Delete(ctx context.Context, key string) {
+	_m.Called(ctx, key)
+}
+
+// Get provides a mock function with given fields: ctx, key
+func (_m *CacheBackend) Get(ctx context.Context, key string) ([]byte, bool) {
+	ret := _m.Called(ctx, key)
+
+	var r0 []byte
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]byte, bool)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []byte); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Set provides a mock function with given fields: ctx, key, value, ttl
+func (_m *CacheBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	_m.Called(ctx, key, value, ttl)
+}
+
+// NewCacheBackend creates a new instance of CacheBackend. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCacheBackend(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CacheBackend {
+	mock := &CacheBackend{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
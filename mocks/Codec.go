@@ -0,0 +1,94 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// Codec is an autogenerated mock type for the Codec type
+type Codec struct {
+	mock.Mock
+}
+
+// ContentType provides a mock function with given fields:
+func (_m *Codec) ContentType() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Marshal provides a mock function with given fields: v
+func (_m *Codec) Marshal(v interface{}) ([]byte, error) {
+	ret := _m.Called(v)
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(interface{}) ([]byte, error)); ok {
+		return rf(v)
+	}
+	if rf, ok := ret.Get(0).(func(interface{}) []byte); ok {
+		r0 = rf(v)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(interface{}) error); ok {
+		r1 = rf(v)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MediaTypes provides a mock function with given fields:
+func (_m *Codec) MediaTypes() []string {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Unmarshal provides a mock function with given fields: data, v
+func (_m *Codec) Unmarshal(data []byte, v interface{}) error {
+	ret := _m.Called(data, v)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]byte, interface{}) error); ok {
+		r0 = rf(data, v)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCodec creates a new instance of Codec. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCodec(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Codec {
+	mock := &Codec{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	entity "github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Expander is an autogenerated mock type for the Expander type
+type Expander struct {
+	mock.Mock
+}
+
+// Expand provides a mock function with given fields: ctx, persons
+func (_m *Expander) Expand(ctx context.Context, persons []*entity.Person) error {
+	ret := _m.Called(ctx, persons)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*entity.Person) error); ok {
+		r0 = rf(ctx, persons)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *Expander) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// NewExpander creates a new instance of Expander. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewExpander(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Expander {
+	mock := &Expander{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,63 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	middleware "github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdempotencyStore is an autogenerated mock type for the IdempotencyStore type
+type IdempotencyStore struct {
+	mock.Mock
+}
+
+// Complete provides a mock function with given fields: key, record
+func (_m *IdempotencyStore) Complete(key string, record *middleware.IdempotencyRecord) {
+	_m.Called(key, record)
+}
+
+// Release provides a mock function with given fields: key
+func (_m *IdempotencyStore) Release(key string) {
+	_m.Called(key)
+}
+
+// Reserve provides a mock function with given fields: key, record
+func (_m *IdempotencyStore) Reserve(key string, record *middleware.IdempotencyRecord) (*middleware.IdempotencyRecord, bool) {
+	ret := _m.Called(key, record)
+
+	var r0 *middleware.IdempotencyRecord
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string, *middleware.IdempotencyRecord) (*middleware.IdempotencyRecord, bool)); ok {
+		return rf(key, record)
+	}
+	if rf, ok := ret.Get(0).(func(string, *middleware.IdempotencyRecord) *middleware.IdempotencyRecord); ok {
+		r0 = rf(key, record)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*middleware.IdempotencyRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *middleware.IdempotencyRecord) bool); ok {
+		r1 = rf(key, record)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// NewIdempotencyStore creates a new instance of IdempotencyStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIdempotencyStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IdempotencyStore {
+	mock := &IdempotencyStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
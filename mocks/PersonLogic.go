@@ -54,25 +54,25 @@ func (_m *PersonLogic) Delete(ctx context.Context, id int) error {
 	return r0
 }
 
-// GetOnePerson provides a mock function with given fields: ctx, id
-func (_m *PersonLogic) GetOnePerson(ctx context.Context, id int) (*entity.Person, error) {
-	ret := _m.Called(ctx, id)
+// GetOnePerson provides a mock function with given fields: ctx, id, fields
+func (_m *PersonLogic) GetOnePerson(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	ret := _m.Called(ctx, id, fields)
 
 	var r0 *entity.Person
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.Person, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) (*entity.Person, error)); ok {
+		return rf(ctx, id, fields)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.Person); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) *entity.Person); ok {
+		r0 = rf(ctx, id, fields)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.Person)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, int, []string) error); ok {
+		r1 = rf(ctx, id, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -80,51 +80,30 @@ func (_m *PersonLogic) GetOnePerson(ctx context.Context, id int) (*entity.Person
 	return r0, r1
 }
 
-// GetPersons provides a mock function with given fields: ctx, email, phone, firstName, page, limit
-func (_m *PersonLogic) GetPersons(ctx context.Context, email string, phone string, firstName string, page int, limit int) ([]*entity.Person, int, int, int, error) {
-	ret := _m.Called(ctx, email, phone, firstName, page, limit)
-
-	var r0 []*entity.Person
-	var r1 int
-	var r2 int
-	var r3 int
-	var r4 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int, int) ([]*entity.Person, int, int, int, error)); ok {
-		return rf(ctx, email, phone, firstName, page, limit)
+// GetPersons provides a mock function with given fields: ctx, email, phone, firstName, fields, page, limit, count
+func (_m *PersonLogic) GetPersons(ctx context.Context, email string, phone string, firstName string, fields []string, page int, limit int, count entity.CountMode) (*entity.PersonPage, error) {
+	ret := _m.Called(ctx, email, phone, firstName, fields, page, limit, count)
+
+	var r0 *entity.PersonPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string, int, int, entity.CountMode) (*entity.PersonPage, error)); ok {
+		return rf(ctx, email, phone, firstName, fields, page, limit, count)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int, int) []*entity.Person); ok {
-		r0 = rf(ctx, email, phone, firstName, page, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string, int, int, entity.CountMode) *entity.PersonPage); ok {
+		r0 = rf(ctx, email, phone, firstName, fields, page, limit, count)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*entity.Person)
+			r0 = ret.Get(0).(*entity.PersonPage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int, int) int); ok {
-		r1 = rf(ctx, email, phone, firstName, page, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []string, int, int, entity.CountMode) error); ok {
+		r1 = rf(ctx, email, phone, firstName, fields, page, limit, count)
 	} else {
-		r1 = ret.Get(1).(int)
-	}
-
-	if rf, ok := ret.Get(2).(func(context.Context, string, string, string, int, int) int); ok {
-		r2 = rf(ctx, email, phone, firstName, page, limit)
-	} else {
-		r2 = ret.Get(2).(int)
-	}
-
-	if rf, ok := ret.Get(3).(func(context.Context, string, string, string, int, int) int); ok {
-		r3 = rf(ctx, email, phone, firstName, page, limit)
-	} else {
-		r3 = ret.Get(3).(int)
-	}
-
-	if rf, ok := ret.Get(4).(func(context.Context, string, string, string, int, int) error); ok {
-		r4 = rf(ctx, email, phone, firstName, page, limit)
-	} else {
-		r4 = ret.Error(4)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2, r3, r4
+	return r0, r1
 }
 
 // Update provides a mock function with given fields: ctx, id, req
@@ -150,25 +150,121 @@ func (_m *PersonRepository) Delete(ctx context.Context, id int) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: ctx, limit, offset
-func (_m *PersonRepository) GetAll(ctx context.Context, limit int, offset int) ([]*entity.Person, error) {
-	ret := _m.Called(ctx, limit, offset)
+// EstimateCountAll provides a mock function with given fields: ctx
+func (_m *PersonRepository) EstimateCountAll(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimateCountAllByEmail provides a mock function with given fields: ctx, email
+func (_m *PersonRepository) EstimateCountAllByEmail(ctx context.Context, email string) (int, error) {
+	ret := _m.Called(ctx, email)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimateCountAllByName provides a mock function with given fields: ctx, name
+func (_m *PersonRepository) EstimateCountAllByName(ctx context.Context, name string) (int, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimateCountAllByPhone provides a mock function with given fields: ctx, phone
+func (_m *PersonRepository) EstimateCountAllByPhone(ctx context.Context, phone string) (int, error) {
+	ret := _m.Called(ctx, phone)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, phone)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, phone)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, phone)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: ctx, fields, limit, offset
+func (_m *PersonRepository) GetAll(ctx context.Context, fields []string, limit int, offset int) ([]*entity.Person, error) {
+	ret := _m.Called(ctx, fields, limit, offset)
 
 	var r0 []*entity.Person
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*entity.Person, error)); ok {
-		return rf(ctx, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int, int) ([]*entity.Person, error)); ok {
+		return rf(ctx, fields, limit, offset)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*entity.Person); ok {
-		r0 = rf(ctx, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int, int) []*entity.Person); ok {
+		r0 = rf(ctx, fields, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entity.Person)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
-		r1 = rf(ctx, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, []string, int, int) error); ok {
+		r1 = rf(ctx, fields, limit, offset)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -176,25 +272,25 @@ func (_m *PersonRepository) GetAll(ctx context.Context, limit int, offset int) (
 	return r0, r1
 }
 
-// GetAllByEmail provides a mock function with given fields: ctx, email, limit, offset
-func (_m *PersonRepository) GetAllByEmail(ctx context.Context, email string, limit int, offset int) ([]*entity.Person, error) {
-	ret := _m.Called(ctx, email, limit, offset)
+// GetAllByEmail provides a mock function with given fields: ctx, email, fields, limit, offset
+func (_m *PersonRepository) GetAllByEmail(ctx context.Context, email string, fields []string, limit int, offset int) ([]*entity.Person, error) {
+	ret := _m.Called(ctx, email, fields, limit, offset)
 
 	var r0 []*entity.Person
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*entity.Person, error)); ok {
-		return rf(ctx, email, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int, int) ([]*entity.Person, error)); ok {
+		return rf(ctx, email, fields, limit, offset)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*entity.Person); ok {
-		r0 = rf(ctx, email, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int, int) []*entity.Person); ok {
+		r0 = rf(ctx, email, fields, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entity.Person)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
-		r1 = rf(ctx, email, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, int, int) error); ok {
+		r1 = rf(ctx, email, fields, limit, offset)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -202,25 +298,25 @@ func (_m *PersonRepository) GetAllByEmail(ctx context.Context, email string, lim
 	return r0, r1
 }
 
-// GetAllByName provides a mock function with given fields: ctx, firstName, limit, offset
-func (_m *PersonRepository) GetAllByName(ctx context.Context, firstName string, limit int, offset int) ([]*entity.Person, error) {
-	ret := _m.Called(ctx, firstName, limit, offset)
+// GetAllByName provides a mock function with given fields: ctx, firstName, fields, limit, offset
+func (_m *PersonRepository) GetAllByName(ctx context.Context, firstName string, fields []string, limit int, offset int) ([]*entity.Person, error) {
+	ret := _m.Called(ctx, firstName, fields, limit, offset)
 
 	var r0 []*entity.Person
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*entity.Person, error)); ok {
-		return rf(ctx, firstName, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int, int) ([]*entity.Person, error)); ok {
+		return rf(ctx, firstName, fields, limit, offset)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*entity.Person); ok {
-		r0 = rf(ctx, firstName, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int, int) []*entity.Person); ok {
+		r0 = rf(ctx, firstName, fields, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entity.Person)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
-		r1 = rf(ctx, firstName, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, int, int) error); ok {
+		r1 = rf(ctx, firstName, fields, limit, offset)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -228,25 +324,25 @@ func (_m *PersonRepository) GetAllByName(ctx context.Context, firstName string,
 	return r0, r1
 }
 
-// GetAllByPhone provides a mock function with given fields: ctx, phone, limit, offset
-func (_m *PersonRepository) GetAllByPhone(ctx context.Context, phone string, limit int, offset int) ([]*entity.Person, error) {
-	ret := _m.Called(ctx, phone, limit, offset)
+// GetAllByPhone provides a mock function with given fields: ctx, phone, fields, limit, offset
+func (_m *PersonRepository) GetAllByPhone(ctx context.Context, phone string, fields []string, limit int, offset int) ([]*entity.Person, error) {
+	ret := _m.Called(ctx, phone, fields, limit, offset)
 
 	var r0 []*entity.Person
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*entity.Person, error)); ok {
-		return rf(ctx, phone, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int, int) ([]*entity.Person, error)); ok {
+		return rf(ctx, phone, fields, limit, offset)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []*entity.Person); ok {
-		r0 = rf(ctx, phone, limit, offset)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int, int) []*entity.Person); ok {
+		r0 = rf(ctx, phone, fields, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entity.Person)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
-		r1 = rf(ctx, phone, limit, offset)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, int, int) error); ok {
+		r1 = rf(ctx, phone, fields, limit, offset)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -280,25 +376,25 @@ func (_m *PersonRepository) GetByEmail(ctx context.Context, email string) (*enti
 	return r0, r1
 }
 
-// GetByID provides a mock function with given fields: ctx, id
-func (_m *PersonRepository) GetByID(ctx context.Context, id int) (*entity.Person, error) {
-	ret := _m.Called(ctx, id)
+// GetByID provides a mock function with given fields: ctx, id, fields
+func (_m *PersonRepository) GetByID(ctx context.Context, id int, fields []string) (*entity.Person, error) {
+	ret := _m.Called(ctx, id, fields)
 
 	var r0 *entity.Person
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.Person, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) (*entity.Person, error)); ok {
+		return rf(ctx, id, fields)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.Person); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) *entity.Person); ok {
+		r0 = rf(ctx, id, fields)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.Person)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, int, []string) error); ok {
+		r1 = rf(ctx, id, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,41 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	middleware "github.com/RomanUtolin/RESTful-CRUD/internall/http/middleware"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RateLimitStore is an autogenerated mock type for the RateLimitStore type
+type RateLimitStore struct {
+	mock.Mock
+}
+
+// Take provides a mock function with given fields: key, rule
+func (_m *RateLimitStore) Take(key string, rule middleware.RateLimitRule) middleware.RateLimitResult {
+	ret := _m.Called(key, rule)
+
+	var r0 middleware.RateLimitResult
+	if rf, ok := ret.Get(0).(func(string, middleware.RateLimitRule) middleware.RateLimitResult); ok {
+		r0 = rf(key, rule)
+	} else {
+		r0 = ret.Get(0).(middleware.RateLimitResult)
+	}
+
+	return r0
+}
+
+// NewRateLimitStore creates a new instance of RateLimitStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateLimitStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateLimitStore {
+	mock := &RateLimitStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,32 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	config "github.com/RomanUtolin/RESTful-CRUD/pkg/config"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Subscriber is an autogenerated mock type for the Subscriber type
+type Subscriber struct {
+	mock.Mock
+}
+
+// ConfigChanged provides a mock function with given fields: cfg
+func (_m *Subscriber) ConfigChanged(cfg *config.Config) {
+	_m.Called(cfg)
+}
+
+// NewSubscriber creates a new instance of Subscriber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSubscriber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Subscriber {
+	mock := &Subscriber{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
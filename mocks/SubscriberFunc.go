@@ -0,0 +1,32 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	config "github.com/RomanUtolin/RESTful-CRUD/pkg/config"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SubscriberFunc is an autogenerated mock type for the SubscriberFunc type
+type SubscriberFunc struct {
+	mock.Mock
+}
+
+// Execute provides a mock function with given fields: cfg
+func (_m *SubscriberFunc) Execute(cfg *config.Config) {
+	_m.Called(cfg)
+}
+
+// NewSubscriberFunc creates a new instance of SubscriberFunc. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSubscriberFunc(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SubscriberFunc {
+	mock := &SubscriberFunc{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
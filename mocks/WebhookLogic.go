@@ -0,0 +1,199 @@
+// Code generated by mockery v2.36.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	entity "github.com/RomanUtolin/RESTful-CRUD/internall/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookLogic is an autogenerated mock type for the WebhookLogic type
+type WebhookLogic struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, req
+func (_m *WebhookLogic) Create(ctx context.Context, req *entity.Webhook) (*entity.Webhook, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *entity.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Webhook) (*entity.Webhook, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Webhook) *entity.Webhook); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *entity.Webhook) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *WebhookLogic) Delete(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx
+func (_m *WebhookLogic) GetAll(ctx context.Context) ([]*entity.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*entity.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*entity.Webhook, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*entity.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *WebhookLogic) GetByID(ctx context.Context, id int) (*entity.Webhook, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.Webhook, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.Webhook); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeliveries provides a mock function with given fields: ctx, webhookID
+func (_m *WebhookLogic) GetDeliveries(ctx context.Context, webhookID int) ([]*entity.WebhookDelivery, error) {
+	ret := _m.Called(ctx, webhookID)
+
+	var r0 []*entity.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entity.WebhookDelivery, error)); ok {
+		return rf(ctx, webhookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entity.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, webhookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Redeliver provides a mock function with given fields: ctx, webhookID, id
+func (_m *WebhookLogic) Redeliver(ctx context.Context, webhookID int, id int) (*entity.WebhookDelivery, error) {
+	ret := _m.Called(ctx, webhookID, id)
+
+	var r0 *entity.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*entity.WebhookDelivery, error)); ok {
+		return rf(ctx, webhookID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *entity.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, webhookID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, id, req
+func (_m *WebhookLogic) Update(ctx context.Context, id int, req *entity.Webhook) (*entity.Webhook, error) {
+	ret := _m.Called(ctx, id, req)
+
+	var r0 *entity.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *entity.Webhook) (*entity.Webhook, error)); ok {
+		return rf(ctx, id, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *entity.Webhook) *entity.Webhook); ok {
+		r0 = rf(ctx, id, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *entity.Webhook) error); ok {
+		r1 = rf(ctx, id, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWebhookLogic creates a new instance of WebhookLogic. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookLogic(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookLogic {
+	mock := &WebhookLogic{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
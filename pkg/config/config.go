@@ -2,53 +2,523 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
-func init() {
-	viper.SetConfigFile("configs/server.json")
-	err := viper.ReadInConfig()
+const (
+	// redacted replaces secrets in the printed configuration.
+	redacted = "******"
+
+	connectBackoff    = 250 * time.Millisecond
+	connectMaxBackoff = 5 * time.Second
+)
+
+// Seconds is a duration written as a number of seconds in the configuration.
+type Seconds int
+
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// Config is the whole configuration of the service, the keys are the mapstructure tags joined by dots.
+type Config struct {
+	Server      Server      `mapstructure:"server" json:"server"`
+	Database    Database    `mapstructure:"database" json:"database"`
+	Log         Log         `mapstructure:"log" json:"log"`
+	Context     Context     `mapstructure:"context" json:"context"`
+	Health      Health      `mapstructure:"health" json:"health"`
+	Metrics     Metrics     `mapstructure:"metrics" json:"metrics"`
+	Cache       Cache       `mapstructure:"cache" json:"cache"`
+	HTTPCache   HTTPCache   `mapstructure:"http_cache" json:"http_cache"`
+	Tracing     Tracing     `mapstructure:"tracing" json:"tracing"`
+	CORS        CORS        `mapstructure:"cors" json:"cors"`
+	Idempotency Idempotency `mapstructure:"idempotency" json:"idempotency"`
+	Auth        Auth        `mapstructure:"auth" json:"auth"`
+	RateLimit   RateLimit   `mapstructure:"rate_limit" json:"rate_limit"`
+	Policy      Policy      `mapstructure:"policy" json:"policy"`
+	Outbox      Outbox      `mapstructure:"outbox" json:"outbox"`
+	Webhooks    Webhooks    `mapstructure:"webhooks" json:"webhooks"`
+
+	// file is the file asked for, path the file read, empty without one
+	file string
+	path string
+}
+
+type Server struct {
+	Address         string  `mapstructure:"address" json:"address" validate:"required"`
+	DrainDelay      Seconds `mapstructure:"drain_delay" json:"drain_delay" validate:"gte=0"`
+	ShutdownTimeout Seconds `mapstructure:"shutdown_timeout" json:"shutdown_timeout" validate:"gt=0"`
+	// TrustedProxies are the CIDRs of the proxies whose X-Forwarded-* headers are believed.
+	TrustedProxies []string `mapstructure:"trusted_proxies" json:"trusted_proxies" validate:"dive,cidr"`
+}
+
+// Database is either a postgres:// URL or the separate settings, the password and TLS files apply to both.
+type Database struct {
+	URL              string `mapstructure:"url" json:"url"`
+	URLFile          string `mapstructure:"url_file" json:"url_file"`
+	Host             string `mapstructure:"host" json:"host" validate:"required_without=URL"`
+	Port             string `mapstructure:"port" json:"port" validate:"required_without=URL,omitempty,numeric"`
+	User             string `mapstructure:"user" json:"user" validate:"required_without=URL"`
+	Pass             string `mapstructure:"pass" json:"pass"`
+	PassFile         string `mapstructure:"pass_file" json:"pass_file"`
+	Name             string `mapstructure:"name" json:"name" validate:"required_without=URL"`
+	SSLMode          string `mapstructure:"sslmode" json:"sslmode" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full"`
+	SSLRootCert      string `mapstructure:"sslrootcert" json:"sslrootcert" validate:"omitempty,file"`
+	SSLCert          string `mapstructure:"sslcert" json:"sslcert" validate:"omitempty,file"`
+	SSLKey           string `mapstructure:"sslkey" json:"sslkey" validate:"omitempty,file"`
+	MigrationVersion int    `mapstructure:"migration_version" json:"migration_version" validate:"gte=0"`
+	// StartupTimeout bounds the retries until the database answers at startup.
+	StartupTimeout Seconds `mapstructure:"startup_timeout" json:"startup_timeout" validate:"gt=0"`
+	Pool           Pool    `mapstructure:"pool" json:"pool"`
+	// Replicas are "host" or "host:port" of read replicas sharing the other settings.
+	Replicas           []string `mapstructure:"replicas" json:"replicas" validate:"dive,required"`
+	ReplicaCheckPeriod Seconds  `mapstructure:"replica_check_period" json:"replica_check_period" validate:"gt=0"`
+	// ReadAfterWrite is how long the reads of a client go to the primary after it wrote.
+	ReadAfterWrite Seconds `mapstructure:"read_after_write" json:"read_after_write" validate:"gte=0"`
+}
+
+type Pool struct {
+	MaxConns          int32   `mapstructure:"max_conns" json:"max_conns" validate:"gt=0"`
+	MinConns          int32   `mapstructure:"min_conns" json:"min_conns" validate:"gte=0,ltefield=MaxConns"`
+	MaxConnLifetime   Seconds `mapstructure:"max_conn_lifetime" json:"max_conn_lifetime" validate:"gt=0"`
+	MaxConnIdleTime   Seconds `mapstructure:"max_conn_idle_time" json:"max_conn_idle_time" validate:"gt=0"`
+	HealthCheckPeriod Seconds `mapstructure:"health_check_period" json:"health_check_period" validate:"gt=0"`
+	// StatementTimeout aborts longer statements on the server, 0 keeps the server setting.
+	StatementTimeout Seconds `mapstructure:"statement_timeout" json:"statement_timeout" validate:"gte=0"`
+}
+
+type Log struct {
+	Level string `mapstructure:"level" json:"level" validate:"oneof=panic fatal error warn warning info debug trace"`
+	Debug bool   `mapstructure:"debug" json:"debug"`
+}
+
+type Context struct {
+	Timeout Seconds `mapstructure:"timeout" json:"timeout" validate:"gt=0"`
+}
+
+type Health struct {
+	Timeout Seconds `mapstructure:"timeout" json:"timeout" validate:"gt=0"`
+}
+
+type Metrics struct {
+	Enabled bool   `mapstructure:"enabled" json:"enabled"`
+	Path    string `mapstructure:"path" json:"path" validate:"startswith=/"`
+}
+
+type Cache struct {
+	Enabled    bool    `mapstructure:"enabled" json:"enabled"`
+	MaxEntries int     `mapstructure:"max_entries" json:"max_entries" validate:"gt=0"`
+	TTL        Seconds `mapstructure:"ttl" json:"ttl" validate:"gt=0"`
+}
+
+type Tracing struct {
+	Exporter    string  `mapstructure:"exporter" json:"exporter" validate:"oneof=otlp stdout none"`
+	Endpoint    string  `mapstructure:"endpoint" json:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure" json:"insecure"`
+	ServiceName string  `mapstructure:"service_name" json:"service_name" validate:"required"`
+	SampleRatio float64 `mapstructure:"sample_ratio" json:"sample_ratio" validate:"gte=0,lte=1"`
+}
+
+type CORS struct {
+	AllowOrigins     []string `mapstructure:"allow_origins" json:"allow_origins"`
+	AllowMethods     []string `mapstructure:"allow_methods" json:"allow_methods"`
+	AllowHeaders     []string `mapstructure:"allow_headers" json:"allow_headers"`
+	ExposeHeaders    []string `mapstructure:"expose_headers" json:"expose_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials" json:"allow_credentials"`
+	MaxAge           Seconds  `mapstructure:"max_age" json:"max_age" validate:"gte=0"`
+}
+
+// HTTPCache sets the Cache-Control of the GET responses of the first rule matching their route.
+type HTTPCache struct {
+	Rules []HTTPCacheRule `mapstructure:"rules" json:"rules" validate:"dive"`
+}
+
+type HTTPCacheRule struct {
+	Route        string `mapstructure:"route" json:"route"`
+	CacheControl string `mapstructure:"cache_control" json:"cache_control" validate:"required"`
+}
+
+type Idempotency struct {
+	TTL Seconds `mapstructure:"ttl" json:"ttl" validate:"gt=0"`
+	// MaxBodySize is the largest request body in bytes kept to compare retries.
+	MaxBodySize int64 `mapstructure:"max_body_size" json:"max_body_size" validate:"gt=0"`
+}
+
+type Auth struct {
+	Enabled      bool     `mapstructure:"enabled" json:"enabled"`
+	Realm        string   `mapstructure:"realm" json:"realm"`
+	PublicRoutes []string `mapstructure:"public_routes" json:"public_routes"`
+	JWT          JWT      `mapstructure:"jwt" json:"jwt"`
+}
+
+type JWT struct {
+	Algorithms    []string `mapstructure:"algorithms" json:"algorithms" validate:"dive,oneof=HS256 HS384 HS512 RS256 RS384 RS512 EdDSA"`
+	Secret        string   `mapstructure:"secret" json:"secret"`
+	SecretFile    string   `mapstructure:"secret_file" json:"secret_file"`
+	PublicKeyFile string   `mapstructure:"public_key_file" json:"public_key_file"`
+	JWKSFile      string   `mapstructure:"jwks_file" json:"jwks_file"`
+	Issuer        string   `mapstructure:"issuer" json:"issuer"`
+	Audience      string   `mapstructure:"audience" json:"audience"`
+	Leeway        Seconds  `mapstructure:"leeway" json:"leeway" validate:"gte=0"`
+}
+
+type RateLimit struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// PerIP limits every client IP before the authentication.
+	PerIP RateLimitQuota  `mapstructure:"per_ip" json:"per_ip"`
+	Rules []RateLimitRule `mapstructure:"rules" json:"rules" validate:"dive"`
+}
+
+type RateLimitQuota struct {
+	Requests int     `mapstructure:"requests" json:"requests" validate:"gt=0"`
+	Period   Seconds `mapstructure:"period" json:"period" validate:"gt=0"`
+}
+
+type RateLimitRule struct {
+	Route    string  `mapstructure:"route" json:"route"`
+	Tier     string  `mapstructure:"tier" json:"tier"`
+	Requests int     `mapstructure:"requests" json:"requests" validate:"gt=0"`
+	Period   Seconds `mapstructure:"period" json:"period" validate:"gt=0"`
+}
+
+type Policy struct {
+	Grants      map[string][]string `mapstructure:"grants" json:"grants"`
+	OwnerGrants map[string][]string `mapstructure:"owner_grants" json:"owner_grants"`
+}
+
+// Outbox relays the person events to Publisher, "stdout" or "file" appending to File.
+type Outbox struct {
+	Enabled        bool    `mapstructure:"enabled" json:"enabled"`
+	Publisher      string  `mapstructure:"publisher" json:"publisher" validate:"oneof=stdout file"`
+	File           string  `mapstructure:"file" json:"file"`
+	BatchSize      int     `mapstructure:"batch_size" json:"batch_size" validate:"gt=0"`
+	Interval       Seconds `mapstructure:"interval" json:"interval" validate:"gt=0"`
+	Retention      Seconds `mapstructure:"retention" json:"retention" validate:"gte=0"`
+	PublishTimeout Seconds `mapstructure:"publish_timeout" json:"publish_timeout" validate:"gt=0"`
+	// BatchTimeout is how long a relay may publish a batch before another instance takes over.
+	BatchTimeout Seconds `mapstructure:"batch_timeout" json:"batch_timeout" validate:"gtefield=PublishTimeout"`
+	MaxAttempts  int     `mapstructure:"max_attempts" json:"max_attempts" validate:"gt=0"`
+}
+
+// Webhooks sends the events relayed by the outbox to the webhook subscriptions.
+type Webhooks struct {
+	Enabled     bool    `mapstructure:"enabled" json:"enabled"`
+	BatchSize   int     `mapstructure:"batch_size" json:"batch_size" validate:"gt=0"`
+	Interval    Seconds `mapstructure:"interval" json:"interval" validate:"gt=0"`
+	Timeout     Seconds `mapstructure:"timeout" json:"timeout" validate:"gt=0"`
+	MaxAttempts int     `mapstructure:"max_attempts" json:"max_attempts" validate:"gt=0"`
+	Backoff     Seconds `mapstructure:"backoff" json:"backoff" validate:"gt=0"`
+	MaxBackoff  Seconds `mapstructure:"max_backoff" json:"max_backoff" validate:"gtefield=Backoff"`
+}
+
+// Validate returns every invalid setting at once, each prefixed with its key.
+func (c Config) Validate() error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+	var errs []error
+	err := validate.Struct(c)
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		for _, fieldErr := range fieldErrs {
+			errs = append(errs, fieldError(fieldErr))
+		}
+	} else if err != nil {
+		errs = append(errs, err)
+	}
+	if c.Database.URL != "" {
+		u, err := url.Parse(c.Database.URL)
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			// the error of url.Parse quotes the URL and its password
+			errs = append(errs, errors.New("database.url: must be a postgres:// or postgresql:// URL"))
+		}
+	}
+	if c.Auth.Enabled {
+		errs = append(errs, c.Auth.JWT.validateKeys()...)
+	}
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowOrigins {
+			if origin == "*" {
+				// any site could make requests with the cookies and credentials of its visitors
+				errs = append(errs, errors.New(`cors.allow_credentials: cannot be used with "*" in cors.allow_origins`))
+				break
+			}
+		}
+	}
+	if c.Outbox.Publisher == "file" && c.Outbox.File == "" {
+		errs = append(errs, errors.New("outbox.file: is required for the file publisher"))
+	}
+	if c.Webhooks.Enabled && !c.Outbox.Enabled {
+		errs = append(errs, errors.New("webhooks.enabled: requires outbox.enabled"))
+	}
+	return errors.Join(errs...)
+}
+
+func fieldError(fieldErr validator.FieldError) error {
+	// the namespace starts with the name of the root struct
+	key := fieldErr.Namespace()
+	key = key[strings.Index(key, ".")+1:]
+	var message string
+	switch fieldErr.Tag() {
+	case "required":
+		message = "is required"
+	case "gt":
+		message = "must be greater than " + fieldErr.Param()
+	case "gte":
+		message = "must be at least " + fieldErr.Param()
+	case "lte":
+		message = "must be at most " + fieldErr.Param()
+	case "oneof":
+		message = "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "startswith":
+		message = fmt.Sprintf("must start with %q", fieldErr.Param())
+	case "required_without":
+		message = "is required without " + toKey(fieldErr.Param())
+	case "ltefield":
+		message = "must be at most " + toKey(fieldErr.Param())
+	case "gtefield":
+		message = "must be at least " + toKey(fieldErr.Param())
+	case "numeric":
+		message = "must be a number"
+	case "file":
+		message = "must be an existing file"
+	case "cidr":
+		message = "must be a CIDR"
+	default:
+		message = "fails " + fieldErr.Tag()
+	}
+	if isSecret(key) {
+		return fmt.Errorf("%s: %s", key, message)
+	}
+	return fmt.Errorf("%s: %s, got %v", key, message, fieldErr.Value())
+}
+
+// toKey turns the name of a field into its key, MaxConns into max_conns.
+func toKey(field string) string {
+	var key strings.Builder
+	for i, r := range field {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(field[i-1])) {
+				key.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		key.WriteRune(r)
+	}
+	return key.String()
+}
+
+// validateKeys checks that every algorithm has a key to verify tokens with, without one no token
+// could be accepted and the API could not be used at all.
+func (j JWT) validateKeys() []error {
+	var errs []error
+	var secretMissing, publicKeyMissing bool
+	for _, algorithm := range j.Algorithms {
+		switch {
+		case strings.HasPrefix(algorithm, "HS"):
+			if j.Secret == "" && !secretMissing {
+				secretMissing = true
+				errs = append(errs, fmt.Errorf("auth.jwt.secret: is required for %s, set auth.jwt.secret_file or %s_AUTH_JWT_SECRET", algorithm, EnvPrefix))
+			}
+		case j.PublicKeyFile == "" && j.JWKSFile == "" && !publicKeyMissing:
+			publicKeyMissing = true
+			errs = append(errs, fmt.Errorf("auth.jwt.public_key_file: is required for %s, or auth.jwt.jwks_file", algorithm))
+		}
+	}
+	if len(j.Algorithms) == 0 {
+		errs = append(errs, errors.New("auth.jwt.algorithms: is required with auth.enabled"))
+	}
+	return errs
+}
+
+// Redacted returns a copy of the configuration with its secrets masked.
+func (c Config) Redacted() Config {
+	if c.Database.Pass != "" {
+		c.Database.Pass = redacted
+	}
+	if c.Database.URL != "" {
+		u, err := url.Parse(c.Database.URL)
+		if err != nil {
+			c.Database.URL = redacted
+		} else {
+			c.Database.URL = u.Redacted()
+		}
+	}
+	if c.Auth.JWT.Secret != "" {
+		c.Auth.JWT.Secret = redacted
+	}
+	return c
+}
+
+// PoolConfig parses the connection settings. The password is set after parsing and never becomes part of
+// a connection string, so that it cannot show up in errors.
+func (c Database) PoolConfig() (*pgxpool.Config, error) {
+	connString, err := c.connString()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	if c.Pass != "" {
+		poolConfig.ConnConfig.Password = c.Pass
+	}
+	poolConfig.MaxConns = c.Pool.MaxConns
+	poolConfig.MinConns = c.Pool.MinConns
+	poolConfig.MaxConnLifetime = c.Pool.MaxConnLifetime.Duration()
+	poolConfig.MaxConnIdleTime = c.Pool.MaxConnIdleTime.Duration()
+	poolConfig.HealthCheckPeriod = c.Pool.HealthCheckPeriod.Duration()
+	if c.Pool.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(c.Pool.StatementTimeout.Duration().Milliseconds(), 10)
+	}
+	return poolConfig, nil
 }
 
-func GetConfigDb() string {
-	dbHost := viper.GetString(`database.host`)
-	dbPort := viper.GetString(`database.port`)
-	dbUser := viper.GetString(`database.user`)
-	dbPass := viper.GetString(`database.pass`)
-	dbName := viper.GetString(`database.name`)
-	sslMode := viper.GetString(`database.sslmode`)
-	dbUrl := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		dbHost, dbPort, dbUser, dbPass, dbName, sslMode)
-	return dbUrl
+// Replica returns the settings of the replica at address, "host" or "host:port".
+func (c Database) Replica(address string) Database {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, c.Port
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err == nil {
+			u.Host = net.JoinHostPort(host, port)
+			if port == "" {
+				u.Host = host
+			}
+			c.URL = u.String()
+		}
+	}
+	c.Host, c.Port = host, port
+	c.Replicas = nil
+	return c
 }
 
-func GetLogger() {
-	logLevel, _ := logrus.ParseLevel(viper.GetString("log_level"))
-	logrus.SetLevel(logLevel)
+func (c Database) connString() (string, error) {
+	tls := [][2]string{
+		{"sslmode", c.SSLMode},
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return "", errors.New("database.url: invalid URL")
+		}
+		query := u.Query()
+		for _, param := range tls {
+			// the parameters of the URL win over the settings
+			if param[1] != "" && !query.Has(param[0]) {
+				query.Set(param[0], param[1])
+			}
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+	params := append([][2]string{
+		{"host", c.Host},
+		{"port", c.Port},
+		{"user", c.User},
+		{"dbname", c.Name},
+	}, tls...)
+	pairs := make([]string, 0, len(params))
+	for _, param := range params {
+		if param[1] != "" {
+			pairs = append(pairs, param[0]+"="+quoteValue(param[1]))
+		}
+	}
+	return strings.Join(pairs, " "), nil
+}
+
+// quoteValue quotes a keyword/value connection string value, escaping quotes and backslashes.
+func quoteValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func GetLogger(cfg Log) {
+	SetLogLevel(cfg)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	if viper.GetBool("debug") {
+	if cfg.Debug {
 		logrus.Infof("Service RUN on DEBUG mode")
 	}
 }
 
-func GetDb() *pgxpool.Pool {
-	ctx := context.Background()
-	dbUrl := GetConfigDb()
-	dbPool, err := pgxpool.New(ctx, dbUrl)
+func SetLogLevel(cfg Log) {
+	logLevel, _ := logrus.ParseLevel(cfg.Level)
+	logrus.SetLevel(logLevel)
+}
+
+// GetReplicaDbs opens a pool per replica without connecting, DBRouter finds out which ones answer.
+func GetReplicaDbs(cfg Database, tracer pgx.QueryTracer) ([]*pgxpool.Pool, error) {
+	pools := make([]*pgxpool.Pool, 0, len(cfg.Replicas))
+	for _, address := range cfg.Replicas {
+		pool, err := newPool(cfg.Replica(address), tracer)
+		if err != nil {
+			for _, pool := range pools {
+				pool.Close()
+			}
+			return nil, fmt.Errorf("database replica %s: %w", address, err)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+func newPool(cfg Database, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+	poolConfig, err := cfg.PoolConfig()
 	if err != nil {
-		logrus.Warning(err)
+		return nil, err
 	}
-	return dbPool
+	poolConfig.ConnConfig.Tracer = tracer
+	// the pool outlives the context of the startup
+	return pgxpool.NewWithConfig(context.Background(), poolConfig)
 }
 
-func GetTimeoutContext() time.Duration {
-	timeoutContext := time.Duration(viper.GetInt("context.timeout")) * time.Second
-	return timeoutContext
+// GetDb opens the pool and pings the database until it answers, retrying with exponential backoff
+// for database.startup_timeout at most. tracer, which may be nil, traces the queries.
+func GetDb(ctx context.Context, cfg Database, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+	dbPool, err := newPool(cfg, tracer)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.StartupTimeout.Duration())
+	defer cancel()
+	backoff := connectBackoff
+	for attempt := 1; ; attempt++ {
+		err = dbPool.Ping(ctx)
+		if err == nil {
+			return dbPool, nil
+		}
+		logrus.WithFields(logrus.Fields{
+			"Attempt":  attempt,
+			"Error":    err,
+			"Retry_in": backoff,
+		}).Warning("database not ready")
+		select {
+		case <-ctx.Done():
+			dbPool.Close()
+			return nil, fmt.Errorf("database: not ready after %s: %w", cfg.StartupTimeout.Duration(), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > connectMaxBackoff {
+			backoff = connectMaxBackoff
+		}
+	}
 }
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+const (
+	// EnvPrefix starts the environment variables overriding the configuration, APP_DATABASE_HOST sets database.host.
+	EnvPrefix = "APP"
+	// DefaultFile is read when it exists and no file is given by --config or APP_CONFIG.
+	DefaultFile = "configs/server.json"
+)
+
+// Load reads the configuration from the file given by --config in args, by APP_CONFIG or DefaultFile,
+// in JSON, YAML or TOML after its extension, over the defaults and under the APP_* environment variables,
+// and validates it.
+func Load(args []string) (*Config, error) {
+	flags, file := newFlagSet("app")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return load(*file)
+}
+
+func newFlagSet(name string) (*pflag.FlagSet, *string) {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	file := flags.String("config", os.Getenv(EnvPrefix+"_CONFIG"), "path of the configuration file (.json, .yaml, .toml)")
+	return flags, file
+}
+
+// load reads a fresh viper instance each time, so that a reload starts from the defaults again.
+func load(file string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	path := file
+	if path == "" {
+		path = DefaultFile
+	}
+	v.SetConfigFile(path)
+	err := v.ReadInConfig()
+	// without a file the defaults and the environment are the whole configuration
+	if err != nil && !(errors.Is(err, fs.ErrNotExist) && file == "") {
+		return nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+	if err != nil {
+		path = ""
+	}
+	secretErr := checkSecretInFile(v)
+	err = readSecretFiles(v)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{file: file, path: path}
+	err = v.Unmarshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("config: decode: %w", err)
+	}
+	err = errors.Join(secretErr, cfg.Validate())
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid settings:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// secretKeys may instead be read from the file named by the same key with a _file suffix, as Docker and
+// Kubernetes secrets are mounted, e.g. APP_DATABASE_PASS_FILE=/run/secrets/db_password. The file wins.
+var secretKeys = []string{"database.pass", "database.url", "auth.jwt.secret"}
+
+func isSecret(key string) bool {
+	for _, secret := range secretKeys {
+		if key == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSecretInFile refuses an HMAC secret written into the configuration file, whoever reads the file
+// could sign tokens. It runs before the _file keys are read, when the value comes from the file or the environment.
+func checkSecretInFile(v *viper.Viper) error {
+	_, fromEnv := os.LookupEnv(EnvPrefix + "_AUTH_JWT_SECRET")
+	if v.InConfig("auth.jwt.secret") && !fromEnv && v.GetString("auth.jwt.secret") != "" {
+		return errors.New("auth.jwt.secret: must be set by auth.jwt.secret_file or " + EnvPrefix + "_AUTH_JWT_SECRET, not in the configuration file")
+	}
+	return nil
+}
+
+func readSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys {
+		path := v.GetString(key + "_file")
+		if path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("config: read %s_file: %w", key, err)
+		}
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+	return nil
+}
+
+func setDefaults(v *viper.Viper) {
+	defaults := map[string]interface{}{
+		"log.level":                         "info",
+		"log.debug":                         false,
+		"server.address":                    ":8080",
+		"server.drain_delay":                5,
+		"server.shutdown_timeout":           25,
+		"server.trusted_proxies":            []string{},
+		"database.url":                      "",
+		"database.url_file":                 "",
+		"database.host":                     "localhost",
+		"database.port":                     "5432",
+		"database.user":                     "postgres",
+		"database.pass":                     "",
+		"database.pass_file":                "",
+		"database.name":                     "dev",
+		"database.sslmode":                  "disable",
+		"database.sslrootcert":              "",
+		"database.sslcert":                  "",
+		"database.sslkey":                   "",
+		"database.migration_version":        6,
+		"database.startup_timeout":          30,
+		"database.replicas":                 []string{},
+		"database.replica_check_period":     5,
+		"database.read_after_write":         5,
+		"database.pool.max_conns":           10,
+		"database.pool.min_conns":           0,
+		"database.pool.max_conn_lifetime":   3600,
+		"database.pool.max_conn_idle_time":  1800,
+		"database.pool.health_check_period": 60,
+		"database.pool.statement_timeout":   0,
+		"health.timeout":                    1,
+		"context.timeout":                   2,
+		"metrics.enabled":                   true,
+		"cache.enabled":                     false,
+		"cache.max_entries":                 10000,
+		"cache.ttl":                         30,
+		"metrics.path":                      "/metrics",
+		"tracing.exporter":                  "none",
+		"tracing.endpoint":                  "localhost:4318",
+		"tracing.insecure":                  true,
+		"tracing.service_name":              "person-api",
+		"tracing.sample_ratio":              1.0,
+		"cors.allow_origins":                []string{"*"},
+		"cors.allow_methods":                []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		"cors.allow_headers":                []string{},
+		"cors.expose_headers":               []string{"ETag", "Link"},
+		"cors.allow_credentials":            false,
+		"cors.max_age":                      600,
+		"http_cache.rules":                  []map[string]interface{}{},
+		"idempotency.ttl":                   86400,
+		"idempotency.max_body_size":         1048576,
+		"outbox.enabled":                    false,
+		"outbox.publisher":                  "stdout",
+		"outbox.file":                       "",
+		"outbox.batch_size":                 100,
+		"outbox.interval":                   1,
+		"outbox.retention":                  86400,
+		"outbox.publish_timeout":            10,
+		"outbox.batch_timeout":              60,
+		"outbox.max_attempts":               10,
+		"webhooks.enabled":                  false,
+		"webhooks.batch_size":               50,
+		"webhooks.interval":                 1,
+		"webhooks.timeout":                  10,
+		"webhooks.max_attempts":             8,
+		"webhooks.backoff":                  10,
+		"webhooks.max_backoff":              3600,
+		"auth.enabled":                      false,
+		"auth.realm":                        "person-api",
+		"auth.public_routes":                []string{"GET /metrics", "GET /healthz", "GET /readyz"},
+		"auth.jwt.algorithms":               []string{"RS256", "EdDSA"},
+		"auth.jwt.secret":                   "",
+		"auth.jwt.secret_file":              "",
+		"auth.jwt.public_key_file":          "",
+		"auth.jwt.jwks_file":                "",
+		"auth.jwt.issuer":                   "",
+		"auth.jwt.audience":                 "",
+		"auth.jwt.leeway":                   30,
+		"rate_limit.enabled":                false,
+		"rate_limit.per_ip.requests":        600,
+		"rate_limit.per_ip.period":          60,
+		"rate_limit.rules":                  []map[string]interface{}{},
+		"policy.grants":                     map[string][]string{},
+		"policy.owner_grants":               map[string][]string{},
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Print writes the effective configuration as JSON, the secrets masked with --redact.
+// It takes the arguments following "config print".
+func Print(args []string, w io.Writer) error {
+	flags, file := newFlagSet("config print")
+	redact := flags.Bool("redact", false, "mask passwords and secrets")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	cfg, err := load(*file)
+	if err != nil {
+		return err
+	}
+	if *redact {
+		*cfg = cfg.Redacted()
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cfg)
+}
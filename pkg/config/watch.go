@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Subscriber is told about every configuration change applied at runtime.
+type Subscriber interface {
+	ConfigChanged(cfg *Config)
+}
+
+type SubscriberFunc func(cfg *Config)
+
+func (f SubscriberFunc) ConfigChanged(cfg *Config) {
+	f(cfg)
+}
+
+// Watcher reloads the configuration when its file changes or on SIGHUP. Only the settings that are safe to
+// change at runtime are applied, changes of the other ones are logged and wait for a restart.
+type Watcher struct {
+	// reloading serializes the reloads, mu guards the fields below
+	reloading   sync.Mutex
+	mu          sync.Mutex
+	current     *Config
+	subscribers []Subscriber
+}
+
+func NewWatcher(cfg *Config) *Watcher {
+	return &Watcher{current: cfg}
+}
+
+// Config returns the configuration in effect.
+func (w *Watcher) Config() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+func (w *Watcher) Subscribe(subscriber Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, subscriber)
+}
+
+// applyRuntime copies the settings that are safe to change at runtime from next into a copy of current.
+func applyRuntime(current, next *Config) *Config {
+	applied := *current
+	applied.Log.Level = next.Log.Level
+	applied.Context.Timeout = next.Context.Timeout
+	applied.Health.Timeout = next.Health.Timeout
+	applied.RateLimit.PerIP = next.RateLimit.PerIP
+	applied.RateLimit.Rules = next.RateLimit.Rules
+	applied.CORS = next.CORS
+	applied.HTTPCache = next.HTTPCache
+	return &applied
+}
+
+// Reload reads the configuration again, applies its runtime settings and notifies the subscribers.
+// An invalid configuration is rejected as a whole.
+func (w *Watcher) Reload() error {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+	current := w.Config()
+	next, err := load(current.file)
+	if err != nil {
+		return err
+	}
+	applied := applyRuntime(current, next)
+	if keys := diffKeys("", reflect.ValueOf(*applied), reflect.ValueOf(*next)); len(keys) > 0 {
+		logrus.WithField("keys", keys).Warning("config: changes need a restart, keeping the running values")
+	}
+	changed := diffKeys("", reflect.ValueOf(*current), reflect.ValueOf(*applied))
+	if len(changed) == 0 {
+		return nil
+	}
+	logrus.WithField("keys", changed).Info("config: reloaded")
+	w.mu.Lock()
+	w.current = applied
+	subscribers := w.subscribers
+	w.mu.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber.ConfigChanged(applied)
+	}
+	return nil
+}
+
+// Watch reloads the configuration on changes of its file and on SIGHUP until ctx is done.
+func (w *Watcher) Watch(ctx context.Context) {
+	reload := func() {
+		err := w.Reload()
+		if err != nil {
+			logrus.WithField("Error", err).Error("config: reload rejected")
+		}
+	}
+	if path := w.Config().path; path != "" {
+		v := viper.New()
+		v.SetConfigFile(path)
+		v.OnConfigChange(func(fsnotify.Event) {
+			if ctx.Err() == nil {
+				reload()
+			}
+		})
+		v.WatchConfig()
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reload()
+		}
+	}
+}
+
+// diffKeys lists the keys, joined by dots after their mapstructure tags, whose values differ between a and b.
+func diffKeys(prefix string, a, b reflect.Value) []string {
+	if a.Kind() != reflect.Struct {
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+	var keys []string
+	for i := 0; i < a.NumField(); i++ {
+		tag := a.Type().Field(i).Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+		if prefix != "" {
+			tag = prefix + "." + tag
+		}
+		keys = append(keys, diffKeys(tag, a.Field(i), b.Field(i))...)
+	}
+	return keys
+}
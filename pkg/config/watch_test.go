@@ -0,0 +1,124 @@
+package config_test
+
+import (
+	"context"
+	"github.com/RomanUtolin/RESTful-CRUD/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+const watchedConfig = `{
+	"server": {"address": ":8080"},
+	"log": {"level": "info"},
+	"context": {"timeout": 2},
+	"cors": {"allow_origins": ["https://a.example.com"]}
+}`
+
+func TestWatcher_Reload(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		waitErr     bool
+		waitNotify  bool
+		waitLevel   string
+		waitTimeout time.Duration
+		waitOrigins []string
+		waitPerIP   int
+	}{
+		{
+			name:        "unchanged",
+			content:     watchedConfig,
+			waitLevel:   "info",
+			waitTimeout: 2 * time.Second,
+			waitOrigins: []string{"https://a.example.com"},
+			waitPerIP:   600,
+		},
+		{
+			name: "runtime settings",
+			content: `{"server": {"address": ":8080"}, "log": {"level": "debug"}, "context": {"timeout": 5},
+				"cors": {"allow_origins": ["https://b.example.com"]}, "rate_limit": {"per_ip": {"requests": 100, "period": 60}}}`,
+			waitNotify:  true,
+			waitLevel:   "debug",
+			waitTimeout: 5 * time.Second,
+			waitOrigins: []string{"https://b.example.com"},
+			waitPerIP:   100,
+		},
+		{
+			name: "restart settings are kept",
+			content: `{"server": {"address": ":9090"}, "database": {"host": "other"}, "log": {"level": "warn"},
+				"context": {"timeout": 2}, "cors": {"allow_origins": ["https://a.example.com"]}}`,
+			waitNotify:  true,
+			waitLevel:   "warn",
+			waitTimeout: 2 * time.Second,
+			waitOrigins: []string{"https://a.example.com"},
+			waitPerIP:   600,
+		},
+		{
+			name:        "invalid",
+			content:     `{"log": {"level": "debug"}, "context": {"timeout": 0}}`,
+			waitErr:     true,
+			waitLevel:   "info",
+			waitTimeout: 2 * time.Second,
+			waitOrigins: []string{"https://a.example.com"},
+			waitPerIP:   600,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := writeFile(t, "server.json", watchedConfig)
+			cfg, err := config.Load([]string{"--config", file})
+			require.NoError(t, err)
+			watcher := config.NewWatcher(cfg)
+			var notified *config.Config
+			watcher.Subscribe(config.SubscriberFunc(func(cfg *config.Config) {
+				notified = cfg
+			}))
+
+			require.NoError(t, os.WriteFile(file, []byte(test.content), 0o600))
+			err = watcher.Reload()
+			if test.waitErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			current := watcher.Config()
+			assert.Equal(t, test.waitNotify, notified != nil)
+			if notified != nil {
+				assert.Equal(t, current, notified)
+			}
+			assert.Equal(t, test.waitLevel, current.Log.Level)
+			assert.Equal(t, test.waitTimeout, current.Context.Timeout.Duration())
+			assert.Equal(t, test.waitOrigins, current.CORS.AllowOrigins)
+			assert.Equal(t, test.waitPerIP, current.RateLimit.PerIP.Requests)
+			assert.Equal(t, ":8080", current.Server.Address)
+			assert.Equal(t, "localhost", current.Database.Host)
+		})
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	file := writeFile(t, "server.json", watchedConfig)
+	cfg, err := config.Load([]string{"--config", file})
+	require.NoError(t, err)
+	watcher := config.NewWatcher(cfg)
+	levels := make(chan string, 10)
+	watcher.Subscribe(config.SubscriberFunc(func(cfg *config.Config) {
+		levels <- cfg.Log.Level
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Watch(ctx)
+	// give the file watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"log": {"level": "debug"}}`), 0o600))
+	select {
+	case level := <-levels:
+		assert.Equal(t, "debug", level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration not reloaded")
+	}
+}